@@ -0,0 +1,28 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+// setCPUAffinity pins the running process pid to the single CPU cpu via
+// sched_setaffinity(2); threads and processes it creates afterwards inherit
+// the mask.
+func setCPUAffinity(pid, cpu int) error {
+	var mask [1024 / 64]uint64 // cpu_set_t
+	if cpu >= len(mask)*64 {
+		return errors.New("no such CPU")
+	}
+	mask[cpu/64] = 1 << (cpu % 64)
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(pid),
+		unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+	if errno == syscall.EINVAL {
+		return errors.New("CPU not available to this process (offline or outside the allowed set)")
+	} else if errno != 0 {
+		return errno
+	}
+	return nil
+}
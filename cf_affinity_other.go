@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// setCPUAffinity needs sched_setaffinity(2), which only Linux has.
+func setCPUAffinity(pid, cpu int) error {
+	return errors.New("only supported on Linux")
+}
@@ -0,0 +1,256 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_batch.go  –  Many solutions, one test set (--solutions-dir <dir>)
+//
+//  RunSolutions  → cfr --solutions-dir <dir> <input> <expected>
+//                  cfr --solutions-dir <dir> --tests <tests>
+//    Builds every source file directly inside dir, each into its own
+//    build/solutions/<file>/ and up to --jobs at once, then runs them one
+//    after another, quietly, on the tests and prints one verdict row per
+//    solution. A compile error is reported as CE and does not stop the
+//    batch. --max-parallel-memory lowers --jobs so that the compilers'
+//    --compile-memory-limit, added up, stays within the budget.
+//
+//  RunGolden     → cfr --golden-dir <dir>
+//    Regression run over solved problems: every subdirectory of dir holds
+//    one solution and its tests (found as for --from-zip). Each is built
+//    into build/golden/<subdir>/ the same way and run on its own tests,
+//    and one row per problem is printed. Fails if any problem does.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// batchRow is the outcome of one solution.
+type batchRow struct {
+	name    string
+	lang    string
+	passed  int
+	tests   int
+	failure string // first failing test, e.g. "WA on 3.in"; "CE" if it did not build
+	elapsed time.Duration
+}
+
+// RunSolutions runs every solution under dir on tests.
+func RunSolutions(dir string, tests []testCase) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("--solutions-dir: %w", err)
+	}
+	var sources []string
+	for _, e := range entries {
+		if _, err := detectLang(e.Name()); err == nil && !e.IsDir() {
+			sources = append(sources, filepath.Join(dir, e.Name()))
+		}
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("--solutions-dir: no source files in %s", dir)
+	}
+	sort.Slice(sources, func(i, j int) bool { return naturalLess(sources[i], sources[j]) })
+
+	printRunName()
+	rows := make([]batchRow, len(sources))
+	buildDirs := make([]string, len(sources))
+	for i, src := range sources {
+		rows[i].name, rows[i].tests = filepath.Base(src), len(tests)
+		buildDirs[i] = filepath.Join("build", "solutions", filepath.Base(src))
+	}
+	sols, err := buildBatch(sources, buildDirs, rows)
+	if err != nil {
+		return err
+	}
+	for i, sol := range sols {
+		if sol == nil {
+			continue
+		}
+		if err := runBatchSolution(sol, tests, &rows[i]); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("┌─ %d solution(s) in %s, %d test(s)\n", len(rows), dir, len(tests))
+	accepted := printBatchRows(rows)
+	fmt.Printf("└─ %d/%d solution(s) accepted\n", accepted, len(rows))
+	return nil
+}
+
+// RunGolden runs every problem directory under dir on its own tests.
+func RunGolden(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("--golden-dir: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("--golden-dir: no problem directories in %s", dir)
+	}
+	sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+
+	printRunName()
+	sources := make([]string, len(names))
+	buildDirs := make([]string, len(names))
+	suites := make([][]testCase, len(names))
+	rows := make([]batchRow, len(names))
+	for i, name := range names {
+		sub := filepath.Join(dir, name)
+		if sources[i], err = findZipSource(sub); err != nil {
+			return fmt.Errorf("--golden-dir: %s: %w", sub, err)
+		}
+		if suites[i], err = discoverTests(sub); err != nil {
+			return err
+		}
+		if len(suites[i]) == 0 {
+			return fmt.Errorf("--golden-dir: %s: no tests found", sub)
+		}
+		if err := applyLimitsManifest(sub, suites[i]); err != nil {
+			return err
+		}
+		buildDirs[i] = filepath.Join("build", "golden", name)
+		rows[i].name, rows[i].tests = name, len(suites[i])
+	}
+	sols, err := buildBatch(sources, buildDirs, rows)
+	if err != nil {
+		return err
+	}
+	for i, sol := range sols {
+		if sol == nil {
+			continue
+		}
+		if err := runBatchSolution(sol, suites[i], &rows[i]); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("┌─ %d problem(s) in %s\n", len(rows), dir)
+	passed := printBatchRows(rows)
+	fmt.Printf("└─ %d/%d problem(s) pass\n", passed, len(rows))
+	if passed < len(rows) {
+		return fmt.Errorf("%d of %d problem(s) failed", len(rows)-passed, len(rows))
+	}
+	return nil
+}
+
+// printBatchRows prints one table row per entry and returns how many passed
+// every test.
+func printBatchRows(rows []batchRow) int {
+	accepted := 0
+	for _, r := range rows {
+		status := fmt.Sprintf("%d/%d", r.passed, r.tests)
+		if r.failure == "" {
+			accepted++
+			fmt.Printf("│  ✓ %-20s %-8s %7s  %9s\n", r.name, r.lang, status, formatDuration(r.elapsed))
+		} else {
+			fmt.Printf("│  ✗ %-20s %-8s %7s  %9s  %s\n", r.name, r.lang, status, formatDuration(r.elapsed), r.failure)
+		}
+	}
+	return accepted
+}
+
+// buildBatch compiles every source into its entry of buildDirs, up to
+// --jobs at a time. A solution that fails to build is left nil and its row
+// marked CE; the others carry on. Each compiler's output is buffered, so
+// concurrent builds don't interleave, and shown as one block per failed
+// build.
+func buildBatch(sources, buildDirs []string, rows []batchRow) ([]*Solution, error) {
+	langs := make([]string, len(sources))
+	for i, src := range sources {
+		lang, err := resolveLang(src)
+		if err != nil {
+			return nil, err
+		}
+		langs[i], rows[i].lang = lang, lang
+		if dockerImage != "" {
+			// dockerMount extends a shared volume list, so not concurrently
+			if err := dockerMount(buildDirs[i], src); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	workers, err := batchWorkers()
+	if err != nil {
+		return nil, err
+	}
+	sols := make([]*Solution, len(sources))
+	errs := make([]error, len(sources))
+	logs := make([]bytes.Buffer, len(sources))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			sols[i], errs[i] = buildSolutionTo(langs[i], src, buildDirs[i], &logs[i])
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			rows[i].failure = "CE"
+			printCompileFailure(rows[i].name, err, logs[i].Bytes())
+		}
+	}
+	return sols, nil
+}
+
+// printCompileFailure shows why name did not build: the error and the
+// compiler output, as one block.
+func printCompileFailure(name string, err error, output []byte) {
+	fmt.Printf("┌─ %s: %v\n", name, err)
+	if out := strings.TrimRight(string(output), "\n"); out != "" {
+		for _, line := range strings.Split(out, "\n") {
+			fmt.Printf("│ %s\n", line)
+		}
+	}
+	fmt.Println("└────────────────────────────────────────────────────────────────────────────")
+}
+
+// batchWorkers is the number of concurrent compiles: --jobs, lowered to what
+// fits in --max-parallel-memory at --compile-memory-limit each.
+func batchWorkers() (int, error) {
+	n := max(jobs, 1)
+	if maxParallelMemory == 0 {
+		return n, nil
+	}
+	if compileMemoryLimit == 0 {
+		return 0, fmt.Errorf("--max-parallel-memory needs --compile-memory-limit, the memory one compile may use")
+	}
+	if fit := max(int(maxParallelMemory/compileMemoryLimit), 1); fit < n {
+		logVerbose("--max-parallel-memory: %d compile(s) at once instead of %d", fit, n)
+		n = fit
+	}
+	return n, nil
+}
+
+// runBatchSolution runs sol on every test without printing per-test
+// verdicts and fills in row.
+func runBatchSolution(sol *Solution, tests []testCase, row *batchRow) error {
+	for i, tc := range tests {
+		res, err := sol.runTest(i+1, tc, filepath.Join(sol.BuildDir, "out.txt"))
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", row.name, tc.Name, err)
+		}
+		row.elapsed += res.Elapsed
+		if res.Verdict == "AC" {
+			row.passed++
+		} else if row.failure == "" {
+			row.failure = res.Verdict + " on " + tc.Name
+		}
+	}
+	return nil
+}
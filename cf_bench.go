@@ -0,0 +1,126 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_bench.go  –  Timing comparison of two solutions (--bench)
+//
+//  cfr <source> <in> --bench <other> [--bench-runs N]
+//
+//  Both sources are built, run once to check that their outputs agree (a
+//  warning is printed if they don't), then run N times each on the same
+//  input. The report lists every run time, mean and min, and the speedup of
+//  <source> over <other>.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// RunBench compares the run times of src and other on inputFile.
+func RunBench(src, other, inputFile string) error {
+	printRunName()
+	var sols [2]*Solution
+	for i, f := range []string{src, other} {
+		lang, err := resolveLang(f)
+		if err != nil {
+			return err
+		}
+		if dockerImage != "" {
+			if err := dockerMount(f, inputFile); err != nil {
+				return err
+			}
+		}
+		// separate build dirs, the two sources may share a base name
+		buildDir := filepath.Join("build", "bench", fmt.Sprint(i+1))
+		if sols[i], err = buildSolution(lang, f, buildDir); err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+	}
+
+	var outputs [2][]byte
+	for i, sol := range sols {
+		var out bytes.Buffer
+		if _, err := sol.timedRun(inputFile, &out); err != nil {
+			return fmt.Errorf("%s: %w", sol.Source, err)
+		}
+		outputs[i] = out.Bytes()
+	}
+	if ok, _, detail := compareOutputs(normalizeOutput(outputs[0]), normalizeOutput(outputs[1])); !ok {
+		fmt.Printf("⚠  outputs of %s and %s differ", src, other)
+		if detail != "" {
+			fmt.Printf(": %s", detail)
+		}
+		fmt.Println(" — timing them anyway")
+	}
+
+	var times [2][]time.Duration
+	for r := 0; r < benchRuns; r++ {
+		// alternate so drift (thermal, cache) affects both sides alike
+		for i, sol := range sols {
+			d, err := sol.timedRun(inputFile, io.Discard)
+			if err != nil {
+				return fmt.Errorf("%s: %w", sol.Source, err)
+			}
+			times[i] = append(times[i], d)
+		}
+	}
+
+	fmt.Printf("┌─ bench on %s, %d run(s) each\n", inputFile, benchRuns)
+	var means [2]time.Duration
+	for i, f := range []string{src, other} {
+		var sum, min time.Duration
+		fmt.Printf("│  %-20s", truncate(f, 20))
+		for j, d := range times[i] {
+			fmt.Printf(" %8s", formatDuration(d))
+			sum += d
+			if j == 0 || d < min {
+				min = d
+			}
+		}
+		means[i] = sum / time.Duration(len(times[i]))
+		fmt.Printf("   mean %s  min %s\n", formatDuration(means[i]), formatDuration(min))
+	}
+	switch {
+	case means[0] == 0 || means[1] == 0:
+		fmt.Println("└─ too fast to compare")
+	case means[0] <= means[1]:
+		fmt.Printf("└─ %s is %.2f× faster than %s\n", src, float64(means[1])/float64(means[0]), other)
+	default:
+		fmt.Printf("└─ %s is %.2f× slower than %s\n", src, float64(means[0])/float64(means[1]), other)
+	}
+	return nil
+}
+
+// timedRun runs the solution once on inputFile with stdout to out and
+// returns the wall-clock time; any failure to run cleanly is an error.
+func (s *Solution) timedRun(inputFile string, out io.Writer) (time.Duration, error) {
+	inFile, err := openInput(inputFile)
+	if err != nil {
+		return 0, err
+	}
+	defer inFile.Close()
+
+	cmd := s.command(context.Background())
+	cmd.Stdin = stdinOf(inFile)
+	cmd.Stdout = out
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	start := time.Now()
+	runErr, err := runSolution(cmd)
+	elapsed := time.Since(start)
+	logVerbose("bench: %s (%d ms)", s.Source, elapsed.Milliseconds())
+	if err != nil {
+		return elapsed, err
+	}
+	if runErr != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return elapsed, fmt.Errorf("run failed: %w: %s", runErr, msg)
+		}
+		return elapsed, fmt.Errorf("run failed: %w", runErr)
+	}
+	return elapsed, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_cache.go  –  Shared compiled-binary cache (--cache-dir <dir>)
+//
+//  A compiled executable is stored under <dir>/<key>, where the key hashes
+//  the language, the source, the compiler command line (with the build dir
+//  and source path factored out, so it is the same in every project), the
+//  compiler's resolved path and version output, and the --docker image. A
+//  later build with the same key copies the binary into the build dir
+//  instead of compiling, so upgrading or switching the compiler misses the
+//  cache. Only builds producing a single executable are cached; Java classes
+//  and Cargo projects always compile.
+//
+//  Headers or modules next to the source are not part of the key.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// cacheKey returns the cache entry name for plan, ok false if the build
+// cannot be cached.
+func cacheKey(plan *buildPlan, buildDir string) (key string, ok bool, err error) {
+	if plan.compile == nil || plan.lang == "rust" && findCargoManifest(plan.source) != "" || len(plan.run) != 1 ||
+		!strings.HasPrefix(plan.run[0], buildDir+string(filepath.Separator)) {
+		return "", false, nil
+	}
+	compiler, err := compilerIdentity(plan.compile[0])
+	if err != nil {
+		logVerbose("--cache-dir: not caching, %v", err)
+		return "", false, nil
+	}
+	src := plan.source
+	if plan.copyFrom != "" {
+		src = plan.copyFrom
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", false, fmt.Errorf("read source: %w", err)
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", plan.lang, dockerImage, compiler)
+	for _, a := range plan.compile {
+		a = strings.ReplaceAll(a, plan.source, "$SRC")
+		a = strings.ReplaceAll(a, buildDir, "$BUILD")
+		fmt.Fprintf(h, "%s\x00", a)
+	}
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))[:32], true, nil
+}
+
+// compilerIDs memoises compilerIdentity per compiler; --solutions-dir builds
+// call it concurrently.
+var compilerIDs struct {
+	sync.Mutex
+	m map[string]string
+}
+
+// compilerIdentity returns the resolved path of compiler and its version
+// output, which go into the cache key.
+func compilerIdentity(compiler string) (string, error) {
+	compilerIDs.Lock()
+	defer compilerIDs.Unlock()
+	if id, ok := compilerIDs.m[compiler]; ok {
+		return id, nil
+	}
+	path := compiler
+	if dockerImage == "" {
+		p, err := exec.LookPath(compiler)
+		if err != nil {
+			return "", err
+		}
+		if path, err = filepath.Abs(p); err != nil {
+			return "", err
+		}
+	}
+	arg := "--version"
+	if base := filepath.Base(compiler); base == "go" || base == "zig" {
+		arg = "version"
+	}
+	var out bytes.Buffer
+	cmd := toolCommand(context.Background(), compiler, arg)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := runChild(cmd); err != nil {
+		return "", fmt.Errorf("%s %s: %w", compiler, arg, err)
+	}
+	if compilerIDs.m == nil {
+		compilerIDs.m = map[string]string{}
+	}
+	id := path + "\x00" + out.String()
+	compilerIDs.m[compiler] = id
+	return id, nil
+}
+
+// checkCacheDir makes sure the cache survives --cleanup of buildDir.
+func checkCacheDir(buildDir string) error {
+	cache, err := filepath.Abs(cacheDir)
+	if err != nil {
+		return err
+	}
+	build, err := filepath.Abs(buildDir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(build, cache); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("--cache-dir %s is inside the build dir %s", cacheDir, buildDir)
+	}
+	return nil
+}
+
+// restoreCached copies the cached binary key to exec. hit is false when there
+// is no such entry.
+func restoreCached(key, exec string) (hit bool, err error) {
+	if _, err := os.Stat(filepath.Join(cacheDir, key)); err != nil {
+		return false, nil
+	}
+	if err := copyExecutable(filepath.Join(cacheDir, key), exec); err != nil {
+		return false, fmt.Errorf("restore from --cache-dir: %w", err)
+	}
+	return true, nil
+}
+
+// storeCached adds exec to the cache as key. The copy is renamed into place
+// so concurrent runs never see a half-written binary.
+func storeCached(key, exec string) error {
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	f, err := os.CreateTemp(cacheDir, "."+key+".*")
+	if err != nil {
+		return fmt.Errorf("store in --cache-dir: %w", err)
+	}
+	tmp := f.Name()
+	f.Close()
+	if err := copyExecutable(exec, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("store in --cache-dir: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(cacheDir, key))
+}
+
+func copyExecutable(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,401 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_compare.go  –  Output normalisation and comparators
+//
+//  Both outputs are normalised first (normalizeOutput), then handed to the
+//  comparator selected with --compare <name>:
+//
+//    bytes             byte-for-byte once all whitespace is removed
+//    exact             byte-for-byte
+//    float             token-wise, numbers within --eps (abs or relative)
+//    mixed             token-wise by type: integers exact, reals within --eps
+//    numeric           token-wise, numbers equal in value (1.0 == 1 == 1e0)
+//    regex             each expected line is a regexp the actual line must match
+//    sorted            same lines in any order
+//    tokens            same whitespace-separated tokens, any spacing
+//    unordered-tokens  same multiset of tokens, any order
+//
+//  A comparator returns whether the outputs match and, if not, a short
+//  human-readable reason shown under the verdict line.
+//
+//  --compare a,b,c chains comparators: the outputs match if any of them
+//  accepts, tried left to right.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type comparator func(expected, actual []byte) (bool, string)
+
+var comparators = map[string]comparator{
+	"bytes":            compareBytes,
+	"exact":            compareExact,
+	"float":            compareFloat,
+	"mixed":            compareMixed,
+	"numeric":          compareNumeric,
+	"regex":            compareRegexLines,
+	"sorted":           compareSortedLines,
+	"tokens":           compareTokens,
+	"unordered-tokens": compareUnorderedTokens,
+}
+
+// compareOutputs runs the --compare chain. It reports the first comparator
+// that accepts, or the mismatch reason of the first one if none does.
+func compareOutputs(expected, actual []byte) (ok bool, by, detail string) {
+	for i, name := range compareModes {
+		ok, d := comparators[name](expected, actual)
+		if ok {
+			return true, name, ""
+		}
+		if i == 0 {
+			detail = d
+		}
+	}
+	return false, "", detail
+}
+
+func comparatorNames() []string {
+	names := make([]string, 0, len(comparators))
+	for n := range comparators {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeOutput prepares an output for comparison. By default all
+// surrounding whitespace is trimmed; with --ignore-trailing-newline only the
+// newline characters at the very end are dropped and everything else,
+// including leading whitespace, must match exactly; with
+// --allow-extra-trailing-blanks trailing blank lines are dropped, see
+// trimTrailingBlankLines; with --strip-one-newline only a single final "\n"
+// is, as judges that strip exactly one do. --strip-trailing-spaces
+// additionally drops spaces and tabs at the end of every line, and
+// --compare-tail N keeps only the last N lines of what remains.
+func normalizeOutput(b []byte) []byte {
+	if stripTrailingSpacesFlag {
+		lines := bytes.Split(b, []byte("\n"))
+		for i, l := range lines {
+			lines[i] = bytes.TrimRight(l, " \t")
+		}
+		b = bytes.Join(lines, []byte("\n"))
+	}
+	switch {
+	case stripOneNewlineFlag:
+		b = bytes.TrimSuffix(b, []byte("\n"))
+	case allowTrailingBlanksFlag:
+		b = trimTrailingBlankLines(b)
+	case ignoreTrailingNewlineFlag:
+		b = bytes.TrimRight(b, "\r\n")
+	default:
+		b = bytes.TrimSpace(b)
+	}
+	if compareTail > 0 {
+		b = lastLines(b, compareTail)
+	}
+	return b
+}
+
+// trimTrailingBlankLines drops the empty or whitespace-only lines at the end
+// of b along with the final line break. Unlike TrimSpace it leaves leading
+// whitespace and the last non-blank line itself alone.
+func trimTrailingBlankLines(b []byte) []byte {
+	for {
+		i := bytes.LastIndexByte(b, '\n')
+		if len(bytes.TrimSpace(b[i+1:])) > 0 {
+			return b
+		}
+		if i < 0 {
+			return b[:0]
+		}
+		b = bytes.TrimSuffix(b[:i], []byte("\r"))
+	}
+}
+
+// lastLines returns the final n lines of b.
+func lastLines(b []byte, n int) []byte {
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] == '\n' {
+			if n--; n == 0 {
+				return b[i+1:]
+			}
+		}
+	}
+	return b
+}
+
+// dropMatchingLines removes every line matching re (--ignore-lines).
+func dropMatchingLines(b []byte, re *regexp.Regexp) []byte {
+	return filterLines(b, re, false)
+}
+
+// keepMatchingLines removes every line of b that re does not match
+// (--compare-stream).
+func keepMatchingLines(b []byte, re *regexp.Regexp) []byte {
+	return filterLines(b, re, true)
+}
+
+// streamLines is b cut down to the --compare-stream lines for the diff, or b
+// itself without --compare-stream.
+func streamLines(b []byte) []byte {
+	if compareStream == nil {
+		return b
+	}
+	return keepMatchingLines(b, compareStream)
+}
+
+// filterLines keeps the lines of b for which matching re equals keep.
+func filterLines(b []byte, re *regexp.Regexp, keep bool) []byte {
+	lines := bytes.Split(b, []byte("\n"))
+	kept := lines[:0]
+	for _, l := range lines {
+		if re.Match(bytes.TrimRight(l, "\r")) == keep {
+			kept = append(kept, l)
+		}
+	}
+	return bytes.Join(kept, []byte("\n"))
+}
+
+// ── Comparators ───────────────────────────────────────────────────────────────
+
+// compareBytes compares both outputs with every whitespace byte removed, so
+// only line breaks and spacing may differ (--diff-bytes). A mismatch shows the
+// first differing position in that collapsed stream.
+func compareBytes(expected, actual []byte) (bool, string) {
+	exp, act := stripWhitespace(expected), stripWhitespace(actual)
+	i := 0
+	for i < len(exp) && i < len(act) && exp[i] == act[i] {
+		i++
+	}
+	if i == len(exp) && i == len(act) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("byte %d without whitespace: expected %s, got %s", i+1, byteContext(exp, i), byteContext(act, i))
+}
+
+// stripWhitespace returns b without its spaces, tabs and line breaks.
+func stripWhitespace(b []byte) []byte {
+	return bytes.Join(bytes.Fields(b), nil)
+}
+
+// byteContext quotes up to 8 bytes of b before and after i, marking the
+// break with a "|".
+func byteContext(b []byte, i int) string {
+	if i >= len(b) {
+		return fmt.Sprintf("end of output after %q", b[max(0, i-8):])
+	}
+	return fmt.Sprintf("%q", string(b[max(0, i-8):i])+"|"+string(b[i:min(len(b), i+8)]))
+}
+
+func compareExact(expected, actual []byte) (bool, string) {
+	if bytes.Equal(expected, actual) {
+		return true, ""
+	}
+	// invisible in the diff table, which drops final newlines
+	if bytes.Equal(bytes.TrimRight(expected, "\r\n"), bytes.TrimRight(actual, "\r\n")) {
+		return false, "only the line breaks at the end differ"
+	}
+	return false, ""
+}
+
+func compareFloat(expected, actual []byte) (bool, string) {
+	exp, act := strings.Fields(string(expected)), strings.Fields(string(actual))
+	if len(exp) != len(act) {
+		return false, fmt.Sprintf("expected %d tokens, got %d", len(exp), len(act))
+	}
+	for i := range exp {
+		if exp[i] == act[i] {
+			continue
+		}
+		e, err1 := strconv.ParseFloat(exp[i], 64)
+		a, err2 := strconv.ParseFloat(act[i], 64)
+		if err1 != nil || err2 != nil {
+			return false, fmt.Sprintf("token %d: expected %q, got %q", i+1, exp[i], act[i])
+		}
+		if d := floatMismatch(e, a); d != "" {
+			return false, fmt.Sprintf("token %d: expected %s, got %s (%s)", i+1, exp[i], act[i], d)
+		}
+	}
+	return true, ""
+}
+
+// floatMismatch says why e and a are not within --eps of each other, either
+// absolutely or relative to e, or returns "" if they are. NaN and ±Inf only
+// match the same special value: every comparison with NaN is false, so the
+// plain eps check would accept it.
+func floatMismatch(e, a float64) string {
+	special := func(f float64) bool { return math.IsNaN(f) || math.IsInf(f, 0) }
+	if special(e) || special(a) {
+		if e == a || math.IsNaN(e) && math.IsNaN(a) {
+			return ""
+		}
+		return "NaN and ±Inf only match themselves"
+	}
+	if diff := math.Abs(e - a); diff > floatEps && diff > floatEps*math.Abs(e) {
+		return fmt.Sprintf("|Δ|=%g > %g", diff, floatEps)
+	}
+	return ""
+}
+
+// integerToken matches the tokens compareMixed requires to match exactly.
+var integerToken = regexp.MustCompile(`^[+-]?\d+$`)
+
+// compareMixed classifies each expected token: integers must be reproduced
+// exactly, reals are compared within --eps like compareFloat, and anything
+// else as a string. Unlike float, "3" vs "3.000001" is a mismatch when the
+// judge printed an integer.
+func compareMixed(expected, actual []byte) (bool, string) {
+	exp, act := strings.Fields(string(expected)), strings.Fields(string(actual))
+	if len(exp) != len(act) {
+		return false, fmt.Sprintf("expected %d tokens, got %d", len(exp), len(act))
+	}
+	for i := range exp {
+		if exp[i] == act[i] {
+			continue
+		}
+		if integerToken.MatchString(exp[i]) {
+			return false, fmt.Sprintf("token %d: expected integer %s, got %q", i+1, exp[i], act[i])
+		}
+		e, err := strconv.ParseFloat(exp[i], 64)
+		if err != nil {
+			return false, fmt.Sprintf("token %d: expected %q, got %q", i+1, exp[i], act[i])
+		}
+		a, err := strconv.ParseFloat(act[i], 64)
+		if err != nil {
+			return false, fmt.Sprintf("token %d: expected real %s, got %q", i+1, exp[i], act[i])
+		}
+		if d := floatMismatch(e, a); d != "" {
+			return false, fmt.Sprintf("token %d: expected %s, got %s (%s)", i+1, exp[i], act[i], d)
+		}
+	}
+	return true, ""
+}
+
+// decimalToken matches the number formats compareNumeric accepts. The
+// exponent is capped at four digits so big.Rat never expands a huge one.
+var decimalToken = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d{1,4})?$`)
+
+// compareNumeric compares tokens by exact value when both are decimal
+// numbers, and as strings otherwise.
+func compareNumeric(expected, actual []byte) (bool, string) {
+	exp, act := strings.Fields(string(expected)), strings.Fields(string(actual))
+	if len(exp) != len(act) {
+		return false, fmt.Sprintf("expected %d tokens, got %d", len(exp), len(act))
+	}
+	var e, a big.Rat
+	for i := range exp {
+		if exp[i] == act[i] {
+			continue
+		}
+		if decimalToken.MatchString(exp[i]) && decimalToken.MatchString(act[i]) {
+			e.SetString(exp[i])
+			a.SetString(act[i])
+			if e.Cmp(&a) == 0 {
+				continue
+			}
+		}
+		return false, fmt.Sprintf("token %d: expected %q, got %q", i+1, exp[i], act[i])
+	}
+	return true, ""
+}
+
+// compareRegexLines treats every expected line as an RE2 pattern that must
+// match the whole actual line at the same position (--expected-regex).
+func compareRegexLines(expected, actual []byte) (bool, string) {
+	exp := strings.Split(string(expected), "\n")
+	act := strings.Split(string(actual), "\n")
+	if len(exp) != len(act) {
+		return false, fmt.Sprintf("expected %d lines, got %d", len(exp), len(act))
+	}
+	for i := range exp {
+		re, err := regexp.Compile(`^(?:` + strings.TrimRight(exp[i], "\r") + `)$`)
+		if err != nil {
+			return false, fmt.Sprintf("line %d: bad pattern: %v", i+1, err)
+		}
+		if !re.MatchString(strings.TrimRight(act[i], "\r")) {
+			return false, fmt.Sprintf("line %d: %q does not match /%s/", i+1, act[i], exp[i])
+		}
+	}
+	return true, ""
+}
+
+func compareSortedLines(expected, actual []byte) (bool, string) {
+	exp := strings.Split(string(expected), "\n")
+	act := strings.Split(string(actual), "\n")
+	sort.Strings(exp)
+	sort.Strings(act)
+	if strings.Join(exp, "\n") == strings.Join(act, "\n") {
+		return true, ""
+	}
+	return false, "lines differ even after sorting"
+}
+
+func compareTokens(expected, actual []byte) (bool, string) {
+	exp, act := strings.Fields(string(expected)), strings.Fields(string(actual))
+	for i := 0; i < len(exp) && i < len(act); i++ {
+		if exp[i] != act[i] {
+			return false, fmt.Sprintf("token %d: expected %q, got %q", i+1, exp[i], act[i])
+		}
+	}
+	if len(exp) != len(act) {
+		return false, fmt.Sprintf("expected %d tokens, got %d", len(exp), len(act))
+	}
+	return true, ""
+}
+
+// compareUnorderedTokens compares the token multisets of both outputs,
+// ignoring lines and order (--unordered-tokens). A mismatch names the tokens
+// missing from or extra in the actual output.
+func compareUnorderedTokens(expected, actual []byte) (bool, string) {
+	count := map[string]int{}
+	for _, t := range strings.Fields(string(expected)) {
+		count[t]++
+	}
+	for _, t := range strings.Fields(string(actual)) {
+		count[t]--
+	}
+	var missing, extra []string
+	for t, n := range count {
+		for ; n > 0; n-- {
+			missing = append(missing, t)
+		}
+		for ; n < 0; n++ {
+			extra = append(extra, t)
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return true, ""
+	}
+	var parts []string
+	if len(missing) > 0 {
+		parts = append(parts, "missing "+tokenSample(missing))
+	}
+	if len(extra) > 0 {
+		parts = append(parts, "extra "+tokenSample(extra))
+	}
+	return false, "token multisets differ: " + strings.Join(parts, ", ")
+}
+
+// tokenSample lists up to five of tokens, sorted, with a count of the rest.
+func tokenSample(tokens []string) string {
+	sort.Slice(tokens, func(i, j int) bool { return naturalLess(tokens[i], tokens[j]) })
+	const shown = 5
+	quoted := make([]string, 0, shown)
+	for i := 0; i < len(tokens) && i < shown; i++ {
+		quoted = append(quoted, strconv.Quote(tokens[i]))
+	}
+	s := strings.Join(quoted, " ")
+	if len(tokens) > shown {
+		s += fmt.Sprintf(" (+%d more)", len(tokens)-shown)
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestCompareFloat(t *testing.T) {
+	defer func(eps float64) { floatEps = eps }(floatEps)
+	floatEps = 1e-6
+	tests := []struct {
+		expected, actual string
+		want             bool
+	}{
+		{"1.5", "1.5000001", true},
+		{"1.5", "1.5001", false},
+		{"1000000", "1000000.5", true}, // relative eps
+		{"0.000001", "0.0000025", false},
+		{"0", "0.0000005", true}, // absolute eps
+		{"1.5", "nan", false},
+		{"nan", "1.5", false},
+		{"nan", "NaN", true},
+		{"1.5", "inf", false},
+		{"inf", "+Inf", true},
+		{"inf", "-inf", false},
+		{"1e308", "inf", false},
+	}
+	for _, tt := range tests {
+		if got, _ := compareFloat([]byte(tt.expected), []byte(tt.actual)); got != tt.want {
+			t.Errorf("compareFloat(%q, %q) = %v, want %v", tt.expected, tt.actual, got, tt.want)
+		}
+	}
+}
+
+func TestCompareMixed(t *testing.T) {
+	defer func(eps float64) { floatEps = eps }(floatEps)
+	floatEps = 1e-6
+	tests := []struct {
+		expected, actual string
+		want             bool
+	}{
+		{"3 1.5", "3 1.5000001", true},
+		{"3 1.5", "3.0000001 1.5", false}, // integers exactly
+		{"3 yes", "3 YES", false},
+		{"1.5", "nan", false},
+		{"1.5", "-inf", false},
+		{"nan", "NaN", true},
+		{"inf", "Inf", true},
+	}
+	for _, tt := range tests {
+		if got, _ := compareMixed([]byte(tt.expected), []byte(tt.actual)); got != tt.want {
+			t.Errorf("compareMixed(%q, %q) = %v, want %v", tt.expected, tt.actual, got, tt.want)
+		}
+	}
+}
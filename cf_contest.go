@@ -274,6 +274,9 @@ func RunProblem(index string, verbose bool) error {
 		// Delegate to make test PROB=<index>
 		target := "test"
 		args := []string{target, "PROB=" + index}
+		if compileJobs > 0 {
+			args = append(args, "-j"+strconv.Itoa(compileJobs))
+		}
 		if verbose {
 			fmt.Printf("┌─ make %s\n", strings.Join(args, " "))
 		}
@@ -281,7 +284,7 @@ func RunProblem(index string, verbose bool) error {
 		cmd.Dir = ccfg.RootDir
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
+		if err := runTimed(cmd); err != nil {
 			return fmt.Errorf("make test failed: %w", err)
 		}
 		return nil
@@ -300,12 +303,15 @@ func RunProblem(index string, verbose bool) error {
 		}
 	}
 
-	lang, err := detectLang(src)
+	lang, err := resolveLang(src)
 	if err != nil {
 		return err
 	}
+	if runName == "" {
+		runName = ccfg.ContestID + index
+	}
 	fmt.Printf("┌─ cfr run %s  (built-in runner)\n", index)
-	return compileAndRun(lang, src, in, out, exp)
+	return compileAndRun(lang, src, in, out, exp, "build")
 }
 
 // ── FetchStatus ───────────────────────────────────────────────────────────────
@@ -700,7 +706,7 @@ func runGit(dir string, args ...string) error {
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &out
-	if err := cmd.Run(); err != nil {
+	if err := runTimed(cmd); err != nil {
 		return fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(out.String()))
 	}
 	logVerbose("git %v → OK", args)
@@ -0,0 +1,110 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// allowCoreDumps raises the soft RLIMIT_CORE of the running process pid to
+// its hard limit, so a crash writes a core file (--capture-core).
+func allowCoreDumps(pid int) error {
+	var lim syscall.Rlimit
+	if err := prlimit(pid, syscall.RLIMIT_CORE, nil, &lim); err != nil {
+		return err
+	}
+	if lim.Max == 0 {
+		return errors.New("hard core size limit is 0 (ulimit -Hc)")
+	}
+	lim.Cur = lim.Max
+	return prlimit(pid, syscall.RLIMIT_CORE, &lim, nil)
+}
+
+// coreDumpReport tells where the kernel put the core of a crashed run, or
+// why there is none. The location follows /proc/sys/kernel/core_pattern:
+// a plain pattern is a file relative to the process's working directory, a
+// pattern starting with '|' hands the core to a program such as
+// systemd-coredump or apport.
+func coreDumpReport(ps *os.ProcessState, dir string) string {
+	ws, ok := ps.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return ""
+	}
+	pattern, err := os.ReadFile("/proc/sys/kernel/core_pattern")
+	if err != nil {
+		return "cannot read core_pattern: " + err.Error()
+	}
+	p := strings.TrimSpace(string(pattern))
+	if strings.HasPrefix(p, "|") {
+		handler := strings.Fields(p[1:])[0]
+		if ws.CoreDump() || strings.Contains(handler, "systemd-coredump") {
+			return fmt.Sprintf("core handed to %s (try: coredumpctl gdb %d)", filepath.Base(handler), ps.Pid())
+		}
+		return fmt.Sprintf("no core dump (%s), core_pattern pipes to %s", ws.Signal(), handler)
+	}
+	if !ws.CoreDump() {
+		return fmt.Sprintf("no core dump written (%s does not dump core, or ulimit/core_pattern prevent it)", ws.Signal())
+	}
+	path := expandCorePattern(p, ps.Pid(), int(ws.Signal()))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if matches, _ := filepath.Glob(path); len(matches) > 0 {
+		path = newestFile(matches)
+	}
+	return "core dumped to " + path
+}
+
+// newestFile returns the most recently modified of paths.
+func newestFile(paths []string) string {
+	best, bestTime := paths[0], time.Time{}
+	for _, p := range paths {
+		if fi, err := os.Stat(p); err == nil && fi.ModTime().After(bestTime) {
+			best, bestTime = p, fi.ModTime()
+		}
+	}
+	return best
+}
+
+// expandCorePattern fills in the core_pattern specifiers cfr knows for
+// sure; the rest (executable name, time, ...) become globs.
+func expandCorePattern(p string, pid, sig int) string {
+	var b strings.Builder
+	hasPid := false
+	for i := 0; i < len(p); i++ {
+		if p[i] != '%' || i+1 == len(p) {
+			b.WriteByte(p[i])
+			continue
+		}
+		i++
+		switch p[i] {
+		case 'p', 'P', 'i', 'I':
+			b.WriteString(strconv.Itoa(pid))
+			hasPid = true
+		case 's':
+			b.WriteString(strconv.Itoa(sig))
+		case 'u':
+			b.WriteString(strconv.Itoa(os.Getuid()))
+		case 'g':
+			b.WriteString(strconv.Itoa(os.Getgid()))
+		case 'h':
+			host, _ := os.Hostname()
+			b.WriteString(host)
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('*')
+		}
+	}
+	if uses, _ := os.ReadFile("/proc/sys/kernel/core_uses_pid"); !hasPid && strings.TrimSpace(string(uses)) == "1" {
+		b.WriteString("." + strconv.Itoa(pid))
+	}
+	return b.String()
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// allowCoreDumps needs prlimit(2), which only Linux has.
+func allowCoreDumps(pid int) error {
+	return errors.New("only supported on Linux")
+}
+
+// coreDumpReport is empty where --capture-core is unsupported.
+func coreDumpReport(ps *os.ProcessState, dir string) string {
+	return ""
+}
@@ -0,0 +1,108 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_docker.go  –  Toolchain command construction, optionally in Docker
+//
+//  Every compiler and solution invocation goes through toolCommand. With
+//  --docker <image> the command becomes
+//
+//    docker run --rm -i -u <uid>:<gid> -v <dir>:<dir> … -w <cwd> <image> <cmd…>
+//
+//  The working directory (which holds build/) and the directories of the
+//  source and test files are mounted at their host paths, so every relative
+//  or absolute path in the command line resolves the same way inside the
+//  container and build artefacts persist between compile and run. stdin and
+//  stdout are streamed through `docker run -i`. Extra environment of a
+//  solution run (--locale) is passed with -e.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// dockerVolumes holds the host directories bind-mounted into the container.
+var dockerVolumes []string
+
+// toolCommand builds the command for a compiler or solution run, wrapped in
+// `docker run` when --docker is set.
+func toolCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return toolCommandIn(ctx, "", nil, name, args...)
+}
+
+// toolCommandIn is toolCommand with the process started in dir instead of
+// the current directory ("" keeps the current directory) and env, as
+// KEY=value, added to the inherited environment.
+func toolCommandIn(ctx context.Context, dir string, env []string, name string, args ...string) *exec.Cmd {
+	if dockerImage == "" {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Dir = dir
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
+		trackChild(cmd)
+		return cmd
+	}
+	cwd := dir
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
+	dargs := []string{"run", "--rm", "-i"}
+	if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 {
+		dargs = append(dargs, "-u", strconv.Itoa(uid)+":"+strconv.Itoa(gid))
+	}
+	for _, dir := range dockerVolumes {
+		dargs = append(dargs, "-v", dir+":"+dir)
+	}
+	for _, kv := range env {
+		dargs = append(dargs, "-e", kv)
+	}
+	dargs = append(dargs, "-w", cwd, dockerImage, name)
+	cmd := exec.CommandContext(ctx, "docker", append(dargs, args...)...)
+	trackChild(cmd)
+	return cmd
+}
+
+// dockerMount checks that docker is available and registers the working
+// directory, --workdir and the directories of files as container volumes.
+func dockerMount(files ...string) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("--docker: docker not found in PATH: %w", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	dirs := []string{cwd}
+	if workDir != "" {
+		abs, err := filepath.Abs(workDir)
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, abs)
+	}
+	for _, f := range files {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, filepath.Dir(abs))
+	}
+	for _, d := range dirs {
+		known := false
+		for _, v := range dockerVolumes {
+			if v == d {
+				known = true
+				break
+			}
+		}
+		if !known {
+			dockerVolumes = append(dockerVolumes, d)
+		}
+	}
+	return nil
+}
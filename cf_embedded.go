@@ -0,0 +1,162 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_embedded.go  –  Examples embedded in the source (--embedded)
+//
+//  RunEmbedded   → cfr <source> --embedded
+//    Runs the solution on the examples written in comments of its own
+//    source file. An example is an INPUT marker line, the input, an OUTPUT
+//    marker line and the expected output, in a block comment
+//
+//      /* INPUT
+//      3
+//      1 2 3
+//      OUTPUT
+//      6
+//      */
+//
+//    or in line comments, one prefix per line (# for Python and shell):
+//
+//      # INPUT
+//      # 3
+//      # OUTPUT
+//      # 6
+//
+//    A block comment ends at */, a line-comment block at the first line
+//    without the prefix. The block form is only recognised in languages
+//    that have /* */ comments (C++, Go, Rust, Java, D); elsewhere a bare
+//    INPUT line, e.g. in a Python docstring, is ignored. Any number of
+//    examples may appear anywhere in the file; each becomes one test case.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type example struct {
+	input, output string
+}
+
+// parseExamples extracts the embedded examples of a source in lang.
+func parseExamples(lang, src string) ([]example, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	prefix := commentPrefix(lang)
+	var examples []example
+	var cur *example
+	inOutput, lineStyle := false, false
+	finish := func(line int) error {
+		if cur != nil && !inOutput {
+			return fmt.Errorf("%s:%d: example has INPUT but no OUTPUT", src, line)
+		}
+		if cur != nil {
+			examples = append(examples, *cur)
+		}
+		cur = nil
+		return nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i, raw := range lines {
+		l := strings.TrimRight(raw, "\r")
+		t := strings.TrimSpace(l)
+		marker := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(t, "/*"), prefix))
+		if marker == "INPUT" {
+			if err := finish(i + 1); err != nil {
+				return nil, err
+			}
+			cur, inOutput, lineStyle = &example{}, false, strings.HasPrefix(t, prefix)
+			if !lineStyle && !hasBlockComments(lang) {
+				cur = nil // nothing would close the block
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if marker == "OUTPUT" && !inOutput {
+			inOutput = true
+			continue
+		}
+
+		closing := false
+		if lineStyle {
+			if !strings.HasPrefix(t, prefix) {
+				if err := finish(i + 1); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			l = strings.TrimPrefix(strings.TrimPrefix(strings.TrimLeft(l, " \t"), prefix), " ")
+		} else if strings.HasSuffix(t, "*/") {
+			closing = true
+			l = strings.TrimSuffix(strings.TrimRight(l, " \t"), "*/")
+		}
+		if !closing || strings.TrimSpace(l) != "" {
+			if inOutput {
+				cur.output += l + "\n"
+			} else {
+				cur.input += l + "\n"
+			}
+		}
+		if closing {
+			if err := finish(i + 1); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := finish(len(lines)); err != nil {
+		return nil, err
+	}
+	return examples, nil
+}
+
+// hasBlockComments reports whether lang has /* */ comments.
+func hasBlockComments(lang string) bool {
+	switch lang {
+	case "cpp", "go", "rust", "java", "dlang":
+		return true
+	}
+	return false
+}
+
+// RunEmbedded writes the examples of src to build/embedded/<k>.in / <k>.out
+// and runs the solution on them.
+func RunEmbedded(src string) error {
+	lang, err := resolveLang(src)
+	if err != nil {
+		return err
+	}
+	examples, err := parseExamples(lang, src)
+	if err != nil {
+		return err
+	}
+	if len(examples) == 0 {
+		return fmt.Errorf("%s: no embedded examples (INPUT … OUTPUT … in a comment)", src)
+	}
+	dir := filepath.Join("build", "embedded")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create embedded dir: %w", err)
+	}
+	tests := make([]testCase, len(examples))
+	for i, ex := range examples {
+		tc := testCase{
+			Name:     fmt.Sprintf("example-%d", i+1),
+			Input:    filepath.Join(dir, fmt.Sprintf("%d.in", i+1)),
+			Expected: filepath.Join(dir, fmt.Sprintf("%d.out", i+1)),
+		}
+		if err := os.WriteFile(tc.Input, []byte(ex.input), 0o644); err != nil {
+			return fmt.Errorf("write example input: %w", err)
+		}
+		if err := os.WriteFile(tc.Expected, []byte(ex.output), 0o644); err != nil {
+			return fmt.Errorf("write example output: %w", err)
+		}
+		tests[i] = tc
+	}
+	return runCases(src, src, tests, "build")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseExamplesPython(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "solution.py")
+	code := `"""
+INPUT
+3
+OUTPUT
+6
+"""
+# INPUT
+# 1 2 3
+# OUTPUT
+# 6
+
+print(sum(map(int, input().split())))
+`
+	if err := os.WriteFile(src, []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseExamples("python", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []example{{input: "1 2 3\n", output: "6\n"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseExamples = %q, want %q", got, want)
+	}
+}
+
+func TestParseExamplesBlockComment(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "solution.cpp")
+	code := "/* INPUT\n3\nOUTPUT\n6\n*/\nint main() {}\n"
+	if err := os.WriteFile(src, []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseExamples("cpp", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []example{{input: "3\n", output: "6\n"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseExamples = %q, want %q", got, want)
+	}
+}
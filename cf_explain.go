@@ -0,0 +1,177 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_explain.go  –  Plain-language plan of a run (--explain)
+//
+//  cfr <source> <in> <out> <exp> --explain
+//
+//  Prints what a single run would do with the options given—language and
+//  how it was chosen, compile and run commands, limits, where the input and
+//  expected output come from and how the outputs are compared—then exits
+//  without compiling or running anything. --dump-commands prints just the
+//  shell commands.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// langNames are the display names of the runner languages.
+var langNames = map[string]string{
+	"cpp": "C++", "go": "Go", "rust": "Rust", "java": "Java", "python": "Python",
+	"bash": "shell", "fortran": "Fortran", "dlang": "D", "zig": "Zig",
+}
+
+// explainRun prints the --explain plan for one run.
+func explainRun(lang, src, in, out, exp string) error {
+	plan, err := planBuild(lang, src, "build")
+	if err != nil {
+		return err
+	}
+	step := 0
+	say := func(format string, args ...interface{}) {
+		step++
+		fmt.Printf("%d. %s\n", step, fmt.Sprintf(format, args...))
+	}
+
+	if langOverride != "" {
+		say("Language: %s, forced with --lang.", langNames[lang])
+	} else {
+		say("Language: %s, detected from the %s extension.", langNames[lang], filepath.Ext(src))
+	}
+	if dockerImage != "" {
+		say("Every command runs inside the Docker image %s.", dockerImage)
+	}
+	if plan.copyFrom != "" {
+		say("Copy %s to %s, as the compiler insists on its own extension.", plan.copyFrom, plan.source)
+	}
+	if plan.pch {
+		say("Precompile <bits/stdc++.h> into build/pch (reused while g++ stays the same).")
+	}
+	if plan.compile != nil {
+		compile := shellJoin(toolCommand(context.Background(), plan.compile[0], plan.compile[1:]...).Args)
+		if cacheDir != "" {
+			say("Compile with `%s`, unless %s already holds a binary for this source and command.", compile, cacheDir)
+		} else {
+			say("Compile with `%s`.", compile)
+		}
+	} else {
+		say("No compile step: %s is interpreted.", langNames[lang])
+	}
+
+	switch {
+	case genCmd != "":
+		say("Input: streamed from `%s` through a pipe.", genCmd)
+	case inlineStdin != "":
+		say("Input: the --stdin text, written to %s.", in)
+	case inputFilter != "":
+		say("Input: %s piped through `%s`.", in, inputFilter)
+	default:
+		say("Input: %s on stdin.", in)
+	}
+	limits := "no time limit"
+	if timeLimit > 0 {
+		limits = "killed as TLE after " + timeLimit.String()
+	}
+	if cpuTimeout > 0 {
+		limits += ", TLE after " + cpuTimeout.String() + " of CPU time"
+	}
+	if memoryLimit > 0 {
+		limits += fmt.Sprintf(", MLE above %d MB peak memory", memoryLimit>>20)
+	}
+	say("Run `%s` with stdout to %s: %s.", shellJoin(plan.run), out, limits)
+	if runsCount > 1 {
+		say("Repeat the run %d times and check every run gives the same output and verdict.", runsCount)
+	}
+	if outputFilter != "" {
+		say("Pass the output through `%s` before comparing.", outputFilter)
+	}
+
+	switch {
+	case saveExpected != "":
+		say("Don't compare: save the normalised output to %s as the expected file.", saveExpected)
+		return nil
+	case expectedCmd != "":
+		say("Expected output: stdout of `%s` on the same input.", expectedCmd)
+	default:
+		say("Expected output: %s.", exp)
+	}
+	say("Compare %s, %s.", describeComparators(), describeNormalization())
+	if rules := describeVerdictRules(); rules != "" {
+		say("%s.", rules)
+	}
+	return nil
+}
+
+// describeComparators phrases the --compare chain.
+func describeComparators() string {
+	names := map[string]string{
+		"bytes":            "the bytes left once all whitespace is removed",
+		"exact":            "exactly",
+		"float":            fmt.Sprintf("numbers within %g", floatEps),
+		"mixed":            fmt.Sprintf("integers exactly and reals within %g", floatEps),
+		"numeric":          "numbers by value",
+		"regex":            "each line against the expected line as a regexp",
+		"sorted":           "lines in any order",
+		"tokens":           "token by token, ignoring spacing",
+		"unordered-tokens": "tokens in any order",
+	}
+	parts := make([]string, len(compareModes))
+	for i, m := range compareModes {
+		parts[i] = names[m]
+	}
+	return strings.Join(parts, ", else ")
+}
+
+// describeNormalization phrases what normalizeOutput does to both sides.
+func describeNormalization() string {
+	var steps []string
+	if stripTrailingSpacesFlag {
+		steps = append(steps, "dropping trailing spaces on every line")
+	}
+	switch {
+	case stripOneNewlineFlag:
+		steps = append(steps, "dropping one final newline")
+	case allowTrailingBlanksFlag:
+		steps = append(steps, "dropping trailing blank lines")
+	case ignoreTrailingNewlineFlag:
+		steps = append(steps, "dropping the final line breaks")
+	default:
+		steps = append(steps, "trimming surrounding whitespace")
+	}
+	if compareTail > 0 {
+		steps = append(steps, fmt.Sprintf("keeping the last %d line(s)", compareTail))
+	}
+	if ignoreLines != nil {
+		steps = append(steps, fmt.Sprintf("removing lines matching %q", ignoreLines))
+	}
+	if compareStream != nil {
+		steps = append(steps, fmt.Sprintf("keeping only the lines matching %q", compareStream))
+	}
+	return "after " + strings.Join(steps, ", ")
+}
+
+// describeVerdictRules phrases the rules that can overturn a matching
+// output, or returns "" if there are none.
+func describeVerdictRules() string {
+	var rules []string
+	if expectedExitCode >= 0 {
+		rules = append(rules, fmt.Sprintf("an exit code other than %d is RE", expectedExitCode))
+	} else if requireExitZeroFlag {
+		rules = append(rules, "a nonzero exit code is RE")
+	}
+	if failOnStderrFlag {
+		rules = append(rules, "any stderr output is RE")
+	}
+	if detectPEFlag {
+		rules = append(rules, "a whitespace-only difference is PE")
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	r := strings.Join(rules, "; ")
+	return strings.ToUpper(r[:1]) + r[1:]
+}
@@ -0,0 +1,69 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_interpreters.go  –  One Python source under several interpreters
+//
+//  cfr <source.py> <in> <out> <exp> --python-bins python3,pypy3
+//
+//  Runs the solution once per interpreter (each used as --toolchain
+//  python=<bin> would be), prints the usual verdict for every run and a
+//  table of verdicts and times, and warns when the interpreters disagree on
+//  the output — e.g. dict ordering, recursion limits or integer formatting.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// RunPythonBins runs src on one test under every --python-bins interpreter.
+func RunPythonBins(src, inputFile, outputFile, expectedFile string) error {
+	if cleanupFlag {
+		removeOnInterrupt("build")
+	}
+	if dockerImage != "" {
+		if err := dockerMount(src, inputFile, expectedFile); err != nil {
+			return err
+		}
+	}
+	tc := testCase{Name: filepath.Base(inputFile), Input: inputFile, Expected: expectedFile}
+	results := make([]*RunResult, len(pythonBins))
+	outputs := map[string]bool{}
+	for i, bin := range pythonBins {
+		toolchain["python"] = bin
+		sol, err := buildSolution("python", src, filepath.Join("build", "python-bins", strconv.Itoa(i+1)))
+		if err != nil {
+			return err
+		}
+		res, err := sol.runTest(i+1, tc, outputFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", bin, err)
+		}
+		res.Name = fmt.Sprintf("%s [%s]", tc.Name, bin)
+		reportResult(res, inputFile)
+		if res.Actual != nil {
+			outputs[string(res.Actual)] = true
+		}
+		results[i] = res
+	}
+
+	fmt.Printf("┌─ %s on %s, %d interpreter(s)\n", src, tc.Name, len(pythonBins))
+	for i, r := range results {
+		mark := "✓"
+		if r.Verdict != "AC" {
+			mark = "✗"
+		}
+		fmt.Printf("│  %s %-20s %-4s %9s\n", mark, pythonBins[i], r.Verdict, formatDuration(r.Elapsed))
+	}
+	if len(outputs) > 1 {
+		fmt.Printf("└─ ⚠  %d distinct outputs: the interpreters disagree\n", len(outputs))
+	} else {
+		fmt.Println("└─ output identical under every interpreter that finished")
+	}
+	if cleanupFlag {
+		os.RemoveAll("build")
+	}
+	return nil
+}
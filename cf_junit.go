@@ -0,0 +1,137 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_junit.go  –  JUnit XML report of a multi-test run (--junit <path>)
+//
+//  One <testsuite> per run with one <testcase> per test. WA and PE become
+//  <failure> elements carrying the differing lines, RE / TLE / MLE become
+//  <error> elements, tests left out by --max-tests carry <skipped/>. Jenkins,
+//  GitLab and most CI dashboards read the format.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr,omitempty"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes the results of the suite label to path, followed by
+// the tests that were not run.
+func writeJUnitReport(path, label string, results []*RunResult, skipped []testCase) error {
+	suite := junitSuite{Name: label, Tests: len(results) + len(skipped), Skipped: len(skipped)}
+	var total time.Duration
+	for _, r := range results {
+		tc := junitCase{Name: r.Name, Classname: label, Time: junitSeconds(r.Elapsed)}
+		switch r.Verdict {
+		case "AC":
+		case "WA", "PE":
+			tc.Failure = &junitProblem{Message: verdictMessage(r), Type: r.Verdict, Text: plainDiff(r.Expected, r.Actual)}
+			suite.Failures++
+		default:
+			tc.Error = &junitProblem{Message: verdictMessage(r), Type: r.Verdict, Text: string(r.Stderr)}
+			suite.Errors++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		total += r.Elapsed
+	}
+	for _, t := range skipped {
+		suite.Cases = append(suite.Cases, junitCase{Name: t.Name, Classname: label, Time: junitSeconds(0),
+			Skipped: &junitSkipped{Message: "left out by --max-tests"}})
+	}
+	suite.Time = junitSeconds(total)
+
+	data, err := xml.MarshalIndent(junitSuites{Suites: []junitSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("--junit: %w", err)
+	}
+	return nil
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// verdictMessage is the one-line summary of a failed test.
+func verdictMessage(r *RunResult) string {
+	msg := r.Verdict
+	switch {
+	case r.Verdict == "TLE" && r.Detail == "": // CPU TLEs say so in Detail
+		msg += " after " + r.TimeLimit.String()
+	case r.Verdict == "MLE":
+		msg += fmt.Sprintf(", peak memory %d MB", r.PeakMem>>20)
+	case r.Verdict == "RE" && r.ExitCode > 0:
+		msg += fmt.Sprintf(", exit code %d", r.ExitCode)
+	}
+	if r.Detail != "" {
+		msg += ": " + r.Detail
+	}
+	return msg
+}
+
+// plainDiff lists the differing lines of two outputs as text, capped at
+// --max-lines rows like the diff table.
+func plainDiff(expected, actual []byte) string {
+	exp := strings.Split(strings.TrimRight(string(expected), "\n"), "\n")
+	act := strings.Split(strings.TrimRight(string(actual), "\n"), "\n")
+	var b strings.Builder
+	rows := 0
+	for i := 0; i < max(len(exp), len(act)); i++ {
+		e, a := "<missing>", "<missing>"
+		if i < len(exp) {
+			e = exp[i]
+		}
+		if i < len(act) {
+			a = act[i]
+		}
+		if e == a {
+			continue
+		}
+		if maxDiffLines > 0 && rows == maxDiffLines {
+			b.WriteString("... (more differences)\n")
+			break
+		}
+		fmt.Fprintf(&b, "line %d:\n  expected: %s\n  actual:   %s\n", i+1, e, a)
+		rows++
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_limits.go  –  Time / memory limits of a run
+//
+//  --time-limit     kills the solution when it runs longer → TLE
+//  --memory-limit   peak resident memory above it → MLE (checked afterwards,
+//                   the run itself is not capped; Linux only)
+//
+//  With --limits-from-header both are read from the top of the source, e.g.
+//
+//    // time: 2s mem: 256m          (C++, Go, Rust, Java)
+//    # time: 1500ms memory: 512mb   (Python, shell)
+//
+//  Limits given on the command line take precedence over the header.
+//
+//  In multi-test runs a limits.json at the top of the test directory
+//  overrides them for single tests, keyed by test name with or without the
+//  .in extension; tests it doesn't list keep the global limits:
+//
+//    {
+//      "3":         {"time": "5s"},
+//      "big/1.in":  {"time": "10s", "memory": "1g"}
+//    }
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commentPrefix is the line-comment marker a header is written in.
+func commentPrefix(lang string) string {
+	switch lang {
+	case "python", "bash":
+		return "#"
+	case "fortran":
+		return "!"
+	default:
+		return "//"
+	}
+}
+
+var (
+	headerTimeRe = regexp.MustCompile(`(?i)\btime\s*[:=]\s*(\S+)`)
+	headerMemRe  = regexp.MustCompile(`(?i)\bmem(?:ory)?\s*[:=]\s*(\S+)`)
+)
+
+// applyHeaderLimits fills timeLimit / memoryLimit from the leading comment
+// block of sourceFile unless they were already set on the command line.
+func applyHeaderLimits(lang, sourceFile string) error {
+	f, err := os.Open(sourceFile)
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	defer f.Close()
+
+	prefix := commentPrefix(lang)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, prefix) {
+			break // header ends at the first line of code
+		}
+		if m := headerTimeRe.FindStringSubmatch(line); m != nil && timeLimit == 0 {
+			d, err := parseDurationFlag(m[1])
+			if err != nil {
+				return fmt.Errorf("%s: header time limit: %w", sourceFile, err)
+			}
+			timeLimit = d
+			logVerbose("limits: time %s from header", d)
+		}
+		if m := headerMemRe.FindStringSubmatch(line); m != nil && memoryLimit == 0 {
+			n, err := parseMemoryFlag(m[1])
+			if err != nil {
+				return fmt.Errorf("%s: header memory limit: %w", sourceFile, err)
+			}
+			memoryLimit = n
+			logVerbose("limits: memory %d MB from header", n>>20)
+		}
+	}
+	return sc.Err()
+}
+
+// limitsManifest is the name of the per-test limits file in a test directory.
+const limitsManifest = "limits.json"
+
+// applyLimitsManifest sets the per-test limits of tests from the
+// limitsManifest under root, if there is one.
+func applyLimitsManifest(root string, tests []testCase) error {
+	path := filepath.Join(root, limitsManifest)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	var entries map[string]struct {
+		Time   string `json:"time"`
+		Memory string `json:"memory"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	byName := map[string]int{}
+	for i, tc := range tests {
+		byName[filepath.ToSlash(tc.Name)] = i
+		byName[strings.TrimSuffix(filepath.ToSlash(tc.Name), ".in")] = i
+	}
+	for name, e := range entries {
+		i, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("%s: no test named %q", path, name)
+		}
+		if e.Time != "" {
+			if tests[i].TimeLimit, err = parseDurationFlag(e.Time); err != nil {
+				return fmt.Errorf("%s: %s: %w", path, name, err)
+			}
+		}
+		if e.Memory != "" {
+			if tests[i].MemoryLimit, err = parseMemoryFlag(e.Memory); err != nil {
+				return fmt.Errorf("%s: %s: memory: %w", path, name, err)
+			}
+		}
+		logVerbose("limits: %s: time %s, memory %d MB from %s", tests[i].Name, tests[i].TimeLimit, tests[i].MemoryLimit>>20, limitsManifest)
+	}
+	return nil
+}
+
+// limits returns the time and memory limit tc runs under: its own from
+// limits.json where set, the global ones otherwise.
+func (tc testCase) limits() (time.Duration, int64) {
+	t, m := tc.TimeLimit, tc.MemoryLimit
+	if t == 0 {
+		t = timeLimit
+	}
+	if m == 0 {
+		m = memoryLimit
+	}
+	return t, m
+}
+
+// parseMemoryFlag accepts a size like 256, 256m, 256mb, 1g or 65536k; a bare
+// number is megabytes. The result is in bytes.
+func parseMemoryFlag(v string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(v))
+	s = strings.TrimSuffix(s, "b")
+	mult := int64(1 << 20)
+	switch {
+	case strings.HasSuffix(s, "k"):
+		mult, s = 1<<10, strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		s = strings.TrimSuffix(s, "m")
+	case strings.HasSuffix(s, "g"):
+		mult, s = 1<<30, strings.TrimSuffix(s, "g")
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 256m, 1g)", v)
+	}
+	return n * mult, nil
+}
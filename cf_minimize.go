@@ -0,0 +1,99 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_minimize.go  –  Counterexample shrinking (--minimize)
+//
+//  With --expected-cmd as the reference (brute-force) solution, a failing
+//  input is shrunk until it stops failing:
+//
+//    1. lines   – drop chunks of lines, halving the chunk size (ddmin-style)
+//    2. numbers – halve every integer token towards 0 while it still fails
+//
+//  A candidate "still fails" when the solution gets the same verdict as on
+//  the original input; a reference command that errors rejects it. Both
+//  solutions are re-run on every candidate, at most minimizeMaxRuns times.
+//  The smallest reproducer is written to build/minimized.in.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const minimizeMaxRuns = 500
+
+// minimize shrinks the input of tc, on which the solution got verdict, and
+// returns the path of the smallest input that still gets it.
+func (s *Solution) minimize(tc testCase, outputFile, verdict string) (string, error) {
+	data, err := os.ReadFile(tc.Input)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join("build", "minimized.in")
+	runs := 0
+	stillFails := func(lines []string) bool {
+		if runs >= minimizeMaxRuns {
+			return false
+		}
+		runs++
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o644); err != nil {
+			return false
+		}
+		res, err := s.runTest(runs, testCase{Name: "minimized.in", Input: path}, outputFile)
+		return err == nil && res.Verdict == verdict
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	before := len(lines)
+
+	// 1. drop chunks of lines
+	for chunk := len(lines) / 2; chunk >= 1; chunk /= 2 {
+		for i := 0; i < len(lines); {
+			candidate := append(append([]string{}, lines[:i]...), lines[min(i+chunk, len(lines)):]...)
+			if len(candidate) > 0 && stillFails(candidate) {
+				lines = candidate
+			} else {
+				i += chunk
+			}
+		}
+	}
+
+	// 2. halve integer tokens
+	for li := range lines {
+		fields := strings.Split(lines[li], " ")
+		for fi, f := range fields {
+			trimmed := strings.TrimRight(f, "\r\n")
+			v, err := strconv.ParseInt(trimmed, 10, 64)
+			if err != nil {
+				continue
+			}
+			suffix := f[len(trimmed):]
+			for v != 0 {
+				fields[fi] = strconv.FormatInt(v/2, 10) + suffix
+				candidate := append([]string{}, lines...)
+				candidate[li] = strings.Join(fields, " ")
+				if !stillFails(candidate) {
+					fields[fi] = strconv.FormatInt(v, 10) + suffix
+					break
+				}
+				lines, v = candidate, v/2
+			}
+		}
+	}
+
+	// the last candidate tried may have passed; write the kept one back
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o644); err != nil {
+		return "", err
+	}
+	fmt.Printf("── minimized: %d → %d line(s) after %d run(s), still %s: %s\n", before, len(lines), runs, verdict, path)
+	if runs >= minimizeMaxRuns {
+		fmt.Printf("   (stopped at the %d run limit, the input may shrink further)\n", minimizeMaxRuns)
+	}
+	return path, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_pager.go  –  Long diffs through a pager (--pager)
+//
+//  cfr <source> <in> <out> <exp> --pager
+//
+//  The diff is rendered into a buffer first. If stdout is a terminal and the
+//  diff has more lines than the terminal is tall, it is piped through $PAGER
+//  (run by sh, default `less -R` so the colours survive); otherwise it is
+//  printed as usual. Never pages under --json or --diff-json, or when
+//  stdout is not a terminal.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+)
+
+const defaultPager = "less -R"
+
+// pageOutput runs render with stdout captured and shows what it printed
+// through the pager when it does not fit in rows terminal lines.
+func pageOutput(rows int, render func()) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		render()
+		return
+	}
+	captured := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		captured <- data
+	}()
+	stdout := os.Stdout
+	os.Stdout = w
+	render()
+	os.Stdout = stdout
+	w.Close()
+	out := <-captured
+	r.Close()
+
+	if bytes.Count(out, []byte("\n")) < rows {
+		os.Stdout.Write(out)
+		return
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = defaultPager
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = bytes.NewReader(out)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// sh exits 127 when the pager itself is not found: print the diff anyway.
+	if err := cmd.Run(); err != nil && (cmd.ProcessState == nil || cmd.ProcessState.ExitCode() == 127) {
+		logVerbose("pager %q: %v", pager, err)
+		os.Stdout.Write(out)
+	}
+}
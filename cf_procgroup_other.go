@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// setProcessGroup is a no-op without Unix process groups.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills only the direct child without Unix process groups.
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
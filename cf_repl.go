@@ -0,0 +1,94 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_repl.go  –  Manual judge for interactive problems (--repl)
+//
+//  cfr <source> --repl
+//
+//  Builds the solution and runs it with the terminal as the judge: every
+//  line typed is sent to the solution when Enter is pressed, and whatever it
+//  writes appears as soon as it flushes. Ctrl-D closes the solution's stdin.
+//  The session ends when the solution exits, with its exit code reported;
+//  lines typed after that are not sent anywhere.
+//
+//  cfr relays the input instead of handing the terminal over, because the
+//  solution runs in its own process group (cf_signal.go) and would be
+//  stopped by SIGTTIN when reading from the terminal directly.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// RunREPL builds src and runs it interactively against the terminal.
+func RunREPL(src string) error {
+	lang, err := resolveLang(src)
+	if err != nil {
+		return err
+	}
+	if dockerImage != "" {
+		if err := dockerMount(src); err != nil {
+			return err
+		}
+	}
+	sol, err := buildSolution(lang, src, "build")
+	if err != nil {
+		return err
+	}
+
+	cmd := sol.command(context.Background())
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("--repl: %w", err)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	fmt.Printf("┌─ repl: %s (%s), type the judge's lines, Ctrl-D ends the input\n", src, lang)
+	if err := startChild(cmd); err != nil {
+		return fmt.Errorf("--repl: %w", err)
+	}
+	// The relay may still be blocked reading a line when the solution exits;
+	// it is simply abandoned, cfr exits right after.
+	go relayLines(os.Stdin, stdin)
+
+	runErr := cmd.Wait()
+	holdIfInterrupted()
+	untrackChild(cmd)
+	var exitErr *exec.ExitError
+	switch {
+	case runErr == nil:
+		fmt.Println("└─ solution exited with code 0")
+	case errors.As(runErr, &exitErr) && exitErr.ExitCode() > 0:
+		fmt.Printf("└─ solution exited with code %d\n", exitErr.ExitCode())
+	default:
+		fmt.Printf("└─ solution failed: %v\n", runErr)
+	}
+	if runErr != nil {
+		return fmt.Errorf("execution failed: %w", runErr)
+	}
+	return nil
+}
+
+// relayLines copies r to w a line at a time until r ends, then closes w. It
+// stops early once w is broken, i.e. the solution exited.
+func relayLines(r io.Reader, w io.WriteCloser) {
+	defer w.Close()
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			if _, werr := io.WriteString(w, line); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+//go:build linux
+
+package main
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// limitAddressSpace sets RLIMIT_AS of the running process pid via
+// prlimit(2); processes it forks afterwards inherit the limit.
+func limitAddressSpace(pid int, bytes int64) error {
+	lim := syscall.Rlimit{Cur: uint64(bytes), Max: uint64(bytes)}
+	return prlimit(pid, syscall.RLIMIT_AS, &lim, nil)
+}
+
+// limitCPUTime sets RLIMIT_CPU of the running process pid to d rounded up
+// to whole seconds: the kernel sends SIGXCPU when it is used up, and SIGKILL
+// a second later if that is ignored.
+func limitCPUTime(pid int, d time.Duration) error {
+	secs := uint64((d + time.Second - 1) / time.Second)
+	lim := syscall.Rlimit{Cur: secs, Max: secs + 1}
+	return prlimit(pid, syscall.RLIMIT_CPU, &lim, nil)
+}
+
+// prlimit sets (newLim) and/or reads (oldLim) a resource limit of pid.
+func prlimit(pid, resource int, newLim, oldLim *syscall.Rlimit) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource),
+		uintptr(unsafe.Pointer(newLim)), uintptr(unsafe.Pointer(oldLim)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
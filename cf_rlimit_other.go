@@ -0,0 +1,18 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// limitAddressSpace needs prlimit(2), which only Linux has.
+func limitAddressSpace(pid int, bytes int64) error {
+	return errors.New("only supported on Linux")
+}
+
+// limitCPUTime needs prlimit(2), which only Linux has.
+func limitCPUTime(pid int, d time.Duration) error {
+	return errors.New("only supported on Linux")
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// peakMemory returns the peak resident set size of a finished process in
+// bytes, 0 if unknown.
+func peakMemory(ps *os.ProcessState) int64 {
+	if ru, ok := ps.SysUsage().(*syscall.Rusage); ok {
+		return ru.Maxrss << 10 // Linux reports KiB
+	}
+	return 0
+}
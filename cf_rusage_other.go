@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// peakMemory is not measured outside Linux; --memory-limit never triggers.
+func peakMemory(ps *os.ProcessState) int64 {
+	return 0
+}
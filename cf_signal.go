@@ -0,0 +1,109 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_signal.go  –  Ctrl-C / SIGTERM handling
+//
+//  Every command built by toolCommandIn starts in its own process group and
+//  is tracked here from startChild until untrackChild, called after it was
+//  waited for. On SIGINT or SIGTERM the handler kills the groups of all
+//  tracked children (so grandchildren like `sleep` in a shell solution die
+//  too), removes the directories registered with removeOnInterrupt and exits
+//  with 128+signal without releasing the lock.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var interrupt struct {
+	sync.Mutex
+	children []*exec.Cmd
+	dirs     []string
+}
+
+// trackChild puts cmd in its own process group and remembers it so it can be
+// killed on interrupt. Cancellation (e.g. --time-limit) kills the group too.
+func trackChild(cmd *exec.Cmd) {
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	interrupt.Lock()
+	interrupt.children = append(interrupt.children, cmd)
+	interrupt.Unlock()
+}
+
+// startChild starts a tracked command with the interrupt lock held, so the
+// handler never reads cmd.Process while Start is still setting it.
+func startChild(cmd *exec.Cmd) error {
+	interrupt.Lock()
+	defer interrupt.Unlock()
+	return cmd.Start()
+}
+
+// untrackChild forgets cmd once it has been waited for, so long sessions
+// (--runs, --bench, many tests) do not keep every finished command alive.
+func untrackChild(cmd *exec.Cmd) {
+	interrupt.Lock()
+	defer interrupt.Unlock()
+	for i, c := range interrupt.children {
+		if c == cmd {
+			interrupt.children = append(interrupt.children[:i], interrupt.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// removeOnInterrupt registers a directory to delete if cfr is interrupted.
+func removeOnInterrupt(dir string) {
+	interrupt.Lock()
+	interrupt.dirs = append(interrupt.dirs, dir)
+	interrupt.Unlock()
+}
+
+// runChild runs a tracked command, see holdIfInterrupted.
+func runChild(cmd *exec.Cmd) error {
+	defer untrackChild(cmd)
+	defer logDuration(cmd, time.Now())
+	if err := startChild(cmd); err != nil {
+		return err
+	}
+	err := cmd.Wait()
+	holdIfInterrupted()
+	return err
+}
+
+// holdIfInterrupted blocks until the handler exits if cfr was interrupted
+// while a child ran, so the killed child is not reported as a failure.
+func holdIfInterrupted() {
+	interrupt.Lock()
+	interrupt.Unlock()
+}
+
+// handleInterrupts installs the SIGINT/SIGTERM handler.
+func handleInterrupts() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-ch
+		interrupt.Lock() // held until exit, see holdIfInterrupted
+		// Only commands not yet waited for are tracked, so just kill them all;
+		// ProcessState is written by Wait and must not be read here.
+		for _, cmd := range interrupt.children {
+			killProcessGroup(cmd)
+		}
+		for _, dir := range interrupt.dirs {
+			os.RemoveAll(dir)
+		}
+
+		code := 130
+		if sig == syscall.SIGTERM {
+			code = 143
+		}
+		os.Stderr.WriteString("\ninterrupted\n")
+		os.Exit(code)
+	}()
+}
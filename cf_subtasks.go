@@ -0,0 +1,99 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_subtasks.go  –  Subtask scoring for multi-test runs (--subtasks)
+//
+//  The spec is a JSON list of groups. Tests are numbered from 1 in the order
+//  they are run (natural order of the discovered files):
+//
+//    [
+//      {"name": "small", "points": 30, "tests": [1, 2]},
+//      {"name": "full",  "points": 70, "tests": [3, 4, 5]}
+//    ]
+//
+//  A group is all-or-nothing: its points count only if every test is AC.
+//  Tests left out by --max-tests count as failed.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type subtask struct {
+	Name   string `json:"name"`
+	Points int    `json:"points"`
+	Tests  []int  `json:"tests"`
+}
+
+// loadSubtasks reads the spec at path and checks it against nTests tests.
+func loadSubtasks(path string, nTests int) ([]subtask, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("--subtasks: %w", err)
+	}
+	var subtasks []subtask
+	if err := json.Unmarshal(data, &subtasks); err != nil {
+		return nil, fmt.Errorf("--subtasks: parse %s: %w", path, err)
+	}
+	for i, st := range subtasks {
+		if st.Name == "" {
+			subtasks[i].Name = fmt.Sprint(i + 1)
+		}
+		if len(st.Tests) == 0 {
+			return nil, fmt.Errorf("--subtasks: group %q has no tests", subtasks[i].Name)
+		}
+		for _, t := range st.Tests {
+			if t < 1 || t > nTests {
+				return nil, fmt.Errorf("--subtasks: group %q refers to test %d, only %d found", subtasks[i].Name, t, nTests)
+			}
+		}
+	}
+	return subtasks, nil
+}
+
+// subtaskScore returns the points earned and the maximum.
+func subtaskScore(subtasks []subtask, results []*RunResult) (score, max int) {
+	for _, st := range subtasks {
+		max += st.Points
+		if subtaskPassed(st, results) {
+			score += st.Points
+		}
+	}
+	return score, max
+}
+
+func subtaskPassed(st subtask, results []*RunResult) bool {
+	for _, t := range st.Tests {
+		if t > len(results) || results[t-1].Verdict != "AC" {
+			return false
+		}
+	}
+	return true
+}
+
+// printScore prints one line per group and the total score.
+func printScore(subtasks []subtask, results []*RunResult) {
+	score, max := 0, 0
+	fmt.Println("┌─ Subtasks")
+	for _, st := range subtasks {
+		max += st.Points
+		var failed []string
+		for _, t := range st.Tests {
+			if t > len(results) {
+				failed = append(failed, fmt.Sprintf("#%d skipped", t))
+			} else if r := results[t-1]; r.Verdict != "AC" {
+				failed = append(failed, fmt.Sprintf("#%d %s", t, r.Verdict))
+			}
+		}
+		if len(failed) == 0 {
+			score += st.Points
+			fmt.Printf("│  ✓ %-12s %3d/%d\n", st.Name, st.Points, st.Points)
+		} else {
+			fmt.Printf("│  ✗ %-12s %3d/%d  (%s)\n", st.Name, 0, st.Points, strings.Join(failed, ", "))
+		}
+	}
+	fmt.Printf("└─ score %d/%d\n", score, max)
+}
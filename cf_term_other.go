@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package main
+
+// terminalHeight reports no terminal, so --pager never pages here.
+func terminalHeight() (int, bool) {
+	return 0, false
+}
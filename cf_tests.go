@@ -0,0 +1,498 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_tests.go  –  Test discovery and multi-test runs
+//
+//  discoverTests finds input/expected pairs anywhere under a directory:
+//    <name>.in  + <name>.out | <name>.ans
+//    in<k>.txt  + exp<k>.txt          (contest workspace layout, k may be empty)
+//
+//  runTests runs an already built Solution on every case, printing one
+//  verdict line per test and a summary.
+//
+//  RunDir     → cfr <source> --tests <dir>
+//    Builds the source and runs it on every test under dir.
+//
+//  With --json both modes print a single JSON report instead of text, and
+//  --junit <path> additionally writes a JUnit XML report (cf_junit.go).
+//
+//  RunSplit   → cfr <source> <in> <out> <exp> --split-on <delim>
+//    Cuts in and exp into aligned cases at delimiter lines and runs each.
+//
+//  RunZip     → cfr --from-zip <archive.zip>
+//    Unpacks source + tests into a temp dir, builds, runs every test and
+//    removes the temp dir again.
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// testCase is one input/expected pair.
+type testCase struct {
+	Name     string // label, e.g. "samples/2.in"
+	Input    string // path to the input file
+	Expected string // path to the expected output
+
+	TimeLimit   time.Duration // from limits.json, 0 = the global limit
+	MemoryLimit int64         // from limits.json, 0 = the global limit
+}
+
+// discoverTests walks root and returns every input file that has a matching
+// expected file, in natural order ("2.in" before "10.in").
+func discoverTests(root string) ([]testCase, error) {
+	var tests []testCase
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		var candidates []string
+		switch {
+		case strings.HasSuffix(name, ".in"):
+			stem := strings.TrimSuffix(name, ".in")
+			candidates = []string{stem + ".out", stem + ".ans"}
+		case strings.HasPrefix(name, "in") && strings.HasSuffix(name, ".txt"):
+			candidates = []string{"exp" + strings.TrimPrefix(name, "in")}
+		}
+		for _, c := range candidates {
+			exp := filepath.Join(filepath.Dir(path), c)
+			if _, err := os.Stat(exp); err == nil {
+				rel, _ := filepath.Rel(root, path)
+				tests = append(tests, testCase{Name: rel, Input: path, Expected: exp})
+				break
+			}
+		}
+		return nil
+	})
+	sort.Slice(tests, func(i, j int) bool { return naturalLess(tests[i].Name, tests[j].Name) })
+	return tests, err
+}
+
+// naturalLess orders strings with embedded numbers by numeric value.
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+// runTests runs sol on every test, reusing outputFile for the actual output,
+// and prints a verdict line per test followed by a summary.
+func runTests(sol *Solution, tests []testCase, outputFile string) ([]*RunResult, error) {
+	results := make([]*RunResult, 0, len(tests))
+	for i, tc := range tests {
+		out := outputFile
+		if keepOutputsFlag {
+			var err error
+			if out, err = actualOutputPath(tc); err != nil {
+				return results, err
+			}
+		}
+		res, err := sol.runTest(i+1, tc, out)
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", tc.Name, err)
+		}
+		if !jsonFlag {
+			reportResult(res, tc.Input)
+		}
+		results = append(results, res)
+	}
+	if !jsonFlag {
+		printSummary(results)
+	}
+	return results, nil
+}
+
+// actualOutputPath names the preserved output of tc for --keep-outputs:
+// build/<test name without extension>.actual, e.g. build/samples/3.actual.
+// It lives under the working directory's build/ in every mode, so the temp
+// dir of --from-zip going away does not take it along.
+func actualOutputPath(tc testCase) (string, error) {
+	path := filepath.Join("build", strings.TrimSuffix(tc.Name, filepath.Ext(tc.Name))+".actual")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("create output dir: %w", err)
+	}
+	return path, nil
+}
+
+func printSummary(results []*RunResult) {
+	passed := 0
+	var total time.Duration
+	for _, r := range results {
+		if r.Verdict == "AC" {
+			passed++
+		}
+		total += r.Elapsed
+	}
+	fmt.Printf("── %d/%d passed  (%s total)\n", passed, len(results), formatDuration(total))
+}
+
+// suiteVerdict sums up a multi-test run for --badge: "AC" if every test
+// passed, else the first failing verdict with the pass count, "WA(2/3)".
+func suiteVerdict(results []*RunResult) string {
+	passed, first := 0, ""
+	for _, r := range results {
+		if r.Verdict == "AC" {
+			passed++
+		} else if first == "" {
+			first = r.Verdict
+		}
+	}
+	if first == "" {
+		return "AC"
+	}
+	return fmt.Sprintf("%s(%d/%d)", first, passed, len(results))
+}
+
+func suiteTime(results []*RunResult) time.Duration {
+	var total time.Duration
+	for _, r := range results {
+		total += r.Elapsed
+	}
+	return total
+}
+
+type jsonTestResult struct {
+	Name     string     `json:"name"`
+	Verdict  string     `json:"verdict"`
+	Ms       int64      `json:"ms"`
+	ExitCode int        `json:"exit_code"`
+	Detail   string     `json:"detail,omitempty"`
+	Diff     []diffLine `json:"diff,omitempty"` // failing output with --diff-json
+}
+
+type jsonSummary struct {
+	Total    int   `json:"total"`
+	Passed   int   `json:"passed"`
+	Failed   int   `json:"failed"`
+	Ms       int64 `json:"ms"`
+	Skipped  int   `json:"skipped,omitempty"`
+	Score    *int  `json:"score,omitempty"`
+	MaxScore *int  `json:"max_score,omitempty"`
+}
+
+// printJSONReport writes the --json document: every test result plus the
+// summary (and the subtask score when --subtasks is set).
+func printJSONReport(results []*RunResult, skipped int, subtasks []subtask) error {
+	report := struct {
+		Name    string           `json:"name,omitempty"` // --name
+		Tests   []jsonTestResult `json:"tests"`
+		Summary jsonSummary      `json:"summary"`
+	}{Name: runName, Tests: []jsonTestResult{}}
+	var total time.Duration
+	for _, r := range results {
+		report.Tests = append(report.Tests, jsonTestResult{
+			Name: r.Name, Verdict: r.Verdict, Ms: r.Elapsed.Milliseconds(), ExitCode: r.ExitCode, Detail: r.Detail,
+		})
+		if diffJSONFlag && (r.Verdict == "WA" || r.Verdict == "PE") {
+			report.Tests[len(report.Tests)-1].Diff = diffJSON(string(streamLines(r.Expected)), string(streamLines(r.Actual)))
+		}
+		if r.Verdict == "AC" {
+			report.Summary.Passed++
+		}
+		total += r.Elapsed
+	}
+	report.Summary.Total = len(results)
+	report.Summary.Failed = len(results) - report.Summary.Passed
+	report.Summary.Ms = total.Milliseconds()
+	report.Summary.Skipped = skipped
+	if subtasks != nil {
+		score, max := subtaskScore(subtasks, results)
+		report.Summary.Score, report.Summary.MaxScore = &score, &max
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// ── RunZip ────────────────────────────────────────────────────────────────────
+
+// RunZip extracts archive into a temp dir, locates the source file by
+// extension, discovers the tests next to it and runs them all. The temp dir
+// is always removed afterwards.
+func RunZip(archive string) error {
+	tmp, err := os.MkdirTemp("", "cfr-zip-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	removeOnInterrupt(tmp)
+
+	if err := unzip(archive, tmp); err != nil {
+		return fmt.Errorf("unzip %s: %w", archive, err)
+	}
+	src, err := findZipSource(tmp)
+	if err != nil {
+		return fmt.Errorf("%s: %w", archive, err)
+	}
+	rel, _ := filepath.Rel(tmp, src)
+	return runSuite(archive+": "+rel, src, tmp, filepath.Join(tmp, "build"))
+}
+
+// RunDir builds src and runs it on every test found under dir.
+func RunDir(src, dir string) error {
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return fmt.Errorf("--tests: %s is not a directory", dir)
+	}
+	return runSuite(src, src, dir, "build")
+}
+
+// runSuite builds src into buildDir and runs it on the tests under root.
+func runSuite(label, src, root, buildDir string) error {
+	tests, err := discoverTests(root)
+	if err != nil {
+		return err
+	}
+	if len(tests) == 0 {
+		return fmt.Errorf("%s: no tests found (expected <name>.in + <name>.out/.ans or in.txt + exp.txt)", label)
+	}
+	if err := applyLimitsManifest(root, tests); err != nil {
+		return err
+	}
+	return runCases(label, src, tests, buildDir)
+}
+
+// runCases builds src into buildDir and runs it on tests, scored per subtask
+// with --subtasks. --cleanup removes buildDir afterwards, as compileAndRun
+// does.
+func runCases(label, src string, tests []testCase, buildDir string) error {
+	if cleanupFlag {
+		removeOnInterrupt(buildDir)
+	}
+	lang, err := resolveLang(src)
+	if err != nil {
+		return err
+	}
+	if limitsFromHeaderFlag {
+		if err := applyHeaderLimits(lang, src); err != nil {
+			return err
+		}
+	}
+	var subtasks []subtask
+	if subtasksFile != "" {
+		if subtasks, err = loadSubtasks(subtasksFile, len(tests)); err != nil {
+			return err
+		}
+	}
+	skipped := 0
+	var cut []testCase // left out by --max-tests, reported as skipped by --junit
+	if maxTests > 0 && len(tests) > maxTests {
+		skipped = len(tests) - maxTests
+		tests, cut = tests[:maxTests], tests[maxTests:]
+	}
+
+	if dockerImage != "" {
+		if err := dockerMount(buildDir, src); err != nil {
+			return err
+		}
+	}
+	printRunName()
+	if !jsonFlag {
+		fmt.Printf("┌─ %s (%s), %d test(s)", label, lang, len(tests))
+		if skipped > 0 {
+			fmt.Printf(", %d skipped (--max-tests)", skipped)
+		}
+		fmt.Println()
+	}
+	sol, err := buildSolution(lang, src, buildDir)
+	if err != nil {
+		return err
+	}
+	results, err := runTests(sol, tests, filepath.Join(buildDir, "out.txt"))
+	if err != nil {
+		return err
+	}
+	if junitPath != "" {
+		suite := label
+		if runName != "" {
+			suite = runName
+		}
+		if err := writeJUnitReport(junitPath, suite, results, cut); err != nil {
+			return err
+		}
+	}
+	if jsonFlag {
+		if err := printJSONReport(results, skipped, subtasks); err != nil {
+			return err
+		}
+	} else if subtasks != nil {
+		printScore(subtasks, results)
+	}
+	if badgeFormat != "" {
+		printBadge(suiteVerdict(results), suiteTime(results), lang)
+	}
+	if cleanupFlag {
+		os.RemoveAll(buildDir)
+	}
+	return nil
+}
+
+// RunSplit builds src and runs it on the cases of a combined input file:
+// input and expected are cut at every line equal to delim (--split-on) into
+// build/split/<k>.in / <k>.out, and case k of one is paired with case k of
+// the other. With --expected-cmd only the input is split.
+func RunSplit(src, input, expected, delim string) error {
+	inputs, err := splitFile(input, delim)
+	if err != nil {
+		return err
+	}
+	var expects []string
+	if expectedCmd == "" {
+		if expects, err = splitFile(expected, delim); err != nil {
+			return err
+		}
+		if len(expects) != len(inputs) {
+			return fmt.Errorf("--split-on: %s has %d case(s) but %s has %d", input, len(inputs), expected, len(expects))
+		}
+	}
+	dir := filepath.Join("build", "split")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create split dir: %w", err)
+	}
+	tests := make([]testCase, len(inputs))
+	for i, in := range inputs {
+		tc := testCase{Name: fmt.Sprintf("case-%d", i+1), Input: filepath.Join(dir, fmt.Sprintf("%d.in", i+1))}
+		if err := os.WriteFile(tc.Input, []byte(in), 0o644); err != nil {
+			return fmt.Errorf("write split input: %w", err)
+		}
+		if expects != nil {
+			tc.Expected = filepath.Join(dir, fmt.Sprintf("%d.out", i+1))
+			if err := os.WriteFile(tc.Expected, []byte(expects[i]), 0o644); err != nil {
+				return fmt.Errorf("write split expected: %w", err)
+			}
+		}
+		tests[i] = tc
+	}
+	return runCases(src, src, tests, "build")
+}
+
+// splitFile cuts a file into the pieces between lines equal to delim
+// (surrounding whitespace ignored). An empty last piece, from a file that
+// ends with the delimiter, is dropped.
+func splitFile(path, delim string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pieces []string
+	var cur strings.Builder
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if strings.TrimSpace(line) == delim {
+			pieces = append(pieces, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteString(line)
+	}
+	if last := cur.String(); strings.TrimSpace(last) != "" || len(pieces) == 0 {
+		pieces = append(pieces, last)
+	}
+	return pieces, nil
+}
+
+// unzip extracts every regular file of archive below dest, rejecting entries
+// that would escape it.
+func unzip(archive, dest string) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		path := filepath.Join(dest, f.Name)
+		if !strings.HasPrefix(path, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal path in archive: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := extractFile(f, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, path string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, rc)
+	return err
+}
+
+// findZipSource returns the only file under root with a runnable extension,
+// preferring solution.* / main.* when there are several.
+func findZipSource(root string) (string, error) {
+	var candidates, preferred []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if _, err := detectLang(path); err == nil {
+			candidates = append(candidates, path)
+			stem := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+			if stem == "solution" || stem == "main" {
+				preferred = append(preferred, path)
+			}
+		}
+		return nil
+	})
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(preferred) == 1:
+		return preferred[0], nil
+	case len(candidates) == 0:
+		return "", fmt.Errorf("no source file found")
+	default:
+		return "", fmt.Errorf("several source files found, name one solution.<ext>")
+	}
+}
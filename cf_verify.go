@@ -0,0 +1,101 @@
+package main
+
+// ─────────────────────────────────────────────────────────────────────────────
+//  cf_verify.go  –  Test folder linter (--verify-tests <dir>)
+//
+//  Checks a test directory without running anything:
+//    • every input has an expected file and every expected file an input
+//    • no input or expected file is empty
+//    • no stray files besides the test pairs (out*.txt runner output,
+//      a top-level limits.json and dotfiles are ignored)
+// ─────────────────────────────────────────────────────────────────────────────
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VerifyTests lints the tests under dir and returns an error if any
+// problem was found.
+func VerifyTests(dir string) error {
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return fmt.Errorf("--verify-tests: %s is not a directory", dir)
+	}
+	var problems []string
+	pairs := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if strings.HasPrefix(name, ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		here := filepath.Dir(path)
+		exists := func(n string) bool {
+			_, err := os.Stat(filepath.Join(here, n))
+			return err == nil
+		}
+
+		switch {
+		case strings.HasSuffix(name, ".in"):
+			stem := strings.TrimSuffix(name, ".in")
+			if exists(stem+".out") || exists(stem+".ans") {
+				pairs++
+			} else {
+				problems = append(problems, rel+": no matching "+stem+".out or "+stem+".ans")
+			}
+		case strings.HasSuffix(name, ".out") || strings.HasSuffix(name, ".ans"):
+			if !exists(strings.TrimSuffix(strings.TrimSuffix(name, ".out"), ".ans") + ".in") {
+				problems = append(problems, rel+": expected file without input")
+			}
+		case strings.HasPrefix(name, "in") && strings.HasSuffix(name, ".txt"):
+			if exp := "exp" + strings.TrimPrefix(name, "in"); exists(exp) {
+				pairs++
+			} else {
+				problems = append(problems, rel+": no matching "+exp)
+			}
+		case strings.HasPrefix(name, "exp") && strings.HasSuffix(name, ".txt"):
+			if !exists("in" + strings.TrimPrefix(name, "exp")) {
+				problems = append(problems, rel+": expected file without input")
+			}
+		case strings.HasPrefix(name, "out") && strings.HasSuffix(name, ".txt"):
+			return nil // runner output
+		case path == filepath.Join(dir, limitsManifest):
+			return nil // per-test limits, see cf_limits.go
+		default:
+			problems = append(problems, rel+": stray file")
+			return nil
+		}
+		if fi, err := d.Info(); err == nil && fi.Size() == 0 {
+			problems = append(problems, rel+": empty")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(problems, func(i, j int) bool { return naturalLess(problems[i], problems[j]) })
+	fmt.Printf("┌─ verify %s: %d test pair(s)\n", dir, pairs)
+	for _, p := range problems {
+		fmt.Printf("│  ✗ %s\n", p)
+	}
+	if len(problems) == 0 {
+		fmt.Println("└─ all good")
+		return nil
+	}
+	fmt.Println("└────────────────────────────────────────────────────────────────────────────")
+	return fmt.Errorf("%s: %d problem(s) in the test folder", dir, len(problems))
+}
@@ -19,7 +19,9 @@ package main
 //    - Authenticated calls (user.status, contest.status) use buildURL which
 //      appends apiKey + time + SHA-512 apiSig per the CF API spec.
 //
-//  Rate limit: 1 req / 2 s. We sleep 500 ms before every call.
+//  Rate limit: 1 req / 2 s. We sleep 500 ms before every call. HTTP 429 / 5xx
+//  and "Call limit exceeded" are retried with exponential backoff (honouring
+//  Retry-After, capped at 30 s), up to --cf-max-attempts tries.
 // ─────────────────────────────────────────────────────────────────────────────
 
 import (
@@ -32,6 +34,7 @@ import (
 	"net/url"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -252,29 +255,77 @@ func (c *CFClient) getAnon(method string, params map[string]string) (json.RawMes
 	return c.doGet(c.buildAnonURL(method, params))
 }
 
+// maxRetryWait caps the delay between two attempts, including the one the
+// server asks for in Retry-After.
+const maxRetryWait = 30 * time.Second
+
 func (c *CFClient) doGet(reqURL string) (json.RawMessage, error) {
 	time.Sleep(500 * time.Millisecond) // CF rate limit: 1 req/2s
-	logVerbose("CF GET %s", reqURL)
+	attempts := max(cfMaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		logVerbose("CF GET %s", reqURL)
+		result, retryAfter, err := c.getOnce(reqURL)
+		if err == nil || retryAfter < 0 || attempt == attempts {
+			return result, err
+		}
+		// Exponential backoff 1s, 2s, 4s … unless CF says otherwise, max 30s.
+		wait := time.Second << (attempt - 1)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		wait = min(wait, maxRetryWait)
+		fmt.Fprintf(os.Stderr, "  ↻ %v — retrying in %s (attempt %d/%d)\n", err, wait, attempt+1, attempts)
+		time.Sleep(wait)
+	}
+}
 
+// getOnce performs one API request. retryAfter is negative if the error is
+// permanent, 0 for a transient error without Retry-After, else the delay
+// the server asked for.
+func (c *CFClient) getOnce(reqURL string) (result json.RawMessage, retryAfter time.Duration, err error) {
 	resp, err := http.Get(reqURL) //nolint:gosec
 	if err != nil {
-		return nil, fmt.Errorf("http get: %w", err)
+		return nil, 0, fmt.Errorf("http get: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read body: %w", err)
+		return nil, 0, fmt.Errorf("read body: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), fmt.Errorf("CF API: HTTP %d", resp.StatusCode)
 	}
 
 	var envelope CFResponse
 	if err := json.Unmarshal(body, &envelope); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+		return nil, -1, fmt.Errorf("parse response: %w", err)
 	}
 	if envelope.Status != "OK" {
-		return nil, fmt.Errorf("CF API: %s", envelope.Comment)
+		// "Call limit exceeded" is CF's rate limiting, worth another try.
+		if strings.Contains(envelope.Comment, "limit exceeded") {
+			return nil, 0, fmt.Errorf("CF API: %s", envelope.Comment)
+		}
+		return nil, -1, fmt.Errorf("CF API: %s", envelope.Comment)
+	}
+	return envelope.Result, 0, nil
+}
+
+// parseRetryAfter reads a Retry-After header (seconds or an HTTP date);
+// 0 if absent or unparseable.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
 	}
-	return envelope.Result, nil
+	return 0
 }
 
 // ── Feature functions ─────────────────────────────────────────────────────────
@@ -1,13 +1,25 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Version is injected at build time via -X main.Version=<tag>.
@@ -16,16 +28,168 @@ var Version = "dev"
 // ── Global flags ──────────────────────────────────────────────────────────────
 
 var (
-	verboseFlag = false
-	cleanupFlag = false
+	verboseFlag       = false
+	cleanupFlag       = false
+	noBuildDirFlag    = false // --no-build-dir: build a single run in a temp dir, always removed
+	showDurationsFlag = false // --show-command-duration: log how long each external command took
+
+	// Toolchain
+	langOverride        = ""                  // --lang: skip extension detection
+	shellBin            = "bash"              // interpreter for .sh solutions (--shell-bin)
+	compileTimeout      time.Duration         // 0 = no limit on the compile step
+	pchFlag             = false               // precompile <bits/stdc++.h> once and reuse it
+	dockerImage         = ""                  // --docker: run compile + execution in this image
+	dCompiler           = "dmd"               // --d-compiler: dmd | ldc2
+	toolchain           = map[string]string{} // --toolchain lang=path: compiler/interpreter per toolchainKeys entry
+	sanitizers          []string              // --sanitize: C++ -fsanitize= list (address, undefined, …)
+	cppLibs             []string              // --libs: appended after the source on the g++ line
+	cppIncludes         []string              // --include: -I<dir> on the g++ line, repeatable
+	goTags              = ""                  // --go-tags: go build -tags, comma-separated
+	goLDFlags           = ""                  // --go-ldflags: go build -ldflags, e.g. "-s -w"
+	pythonBins          []string              // --python-bins: run a Python source under each of these
+	compileMemoryLimit  int64                 // --compile-memory-limit in bytes: RLIMIT_AS of the compiler (Linux)
+	compileJobs         = 0                   // --compile-jobs: cargo -j / make -j, 0 = toolchain default
+	jobs                = runtime.NumCPU()    // --jobs: solutions compiled at once in --solutions-dir / --golden-dir
+	maxParallelMemory   int64                 // --max-parallel-memory in bytes: caps --jobs × --compile-memory-limit, 0 = off
+	noCompileOutputFlag = false               // hide compiler output unless the compile fails
+	workDir             = ""                  // --workdir: cwd of the solution, "" = the source's directory
+	runLocale           = "C"                 // --locale: LC_ALL and LANG of solution runs, "inherit" = unchanged
+	cacheDir            = ""                  // --cache-dir: shared cache of compiled binaries, "" = off
+
+	// Comparison
+	ignoreTrailingNewlineFlag = false             // only strip newlines at EOF instead of TrimSpace
+	allowTrailingBlanksFlag   = false             // only drop trailing blank lines instead of TrimSpace
+	stripOneNewlineFlag       = false             // only drop one final "\n" instead of TrimSpace
+	stripTrailingSpacesFlag   = false             // drop trailing spaces/tabs on every line, both sides
+	compareModes              = []string{"exact"} // --compare: keys into comparators, tried in order
+	floatEps                  = 1e-6              // --eps: tolerance of the float comparator
+	compareTail               = 0                 // --compare-tail: only compare the last N lines, 0 = all
+	ignoreLines               *regexp.Regexp      // --ignore-lines: lines matching it are dropped on both sides
+	compareStream             *regexp.Regexp      // --compare-stream: only lines matching it are compared, both sides
+
+	// Modes
+	fromZip          = ""    // --from-zip: run source + tests packed in an archive
+	testsDir         = ""    // --tests: run the source on every test under this dir
+	verifyTestsDir   = ""    // --verify-tests: lint a test folder, run nothing
+	subtasksFile     = ""    // --subtasks: JSON groups scored all-or-nothing
+	solutionsDir     = ""    // --solutions-dir: run every source in this dir on the same tests
+	goldenDir        = ""    // --golden-dir: run every problem subdir (solution + tests) of this dir
+	maxTests         = 0     // --max-tests: run only the first N tests, 0 = all
+	runsCount        = 1     // --runs: run a single test N times and check the runs agree
+	splitOn          = ""    // --split-on: delimiter line cutting <in> and <exp> into cases
+	minimizeFlag     = false // --minimize: shrink a failing input against --expected-cmd
+	embeddedFlag     = false // --embedded: run the INPUT/OUTPUT examples in the source's comments
+	replFlag         = false // --repl: relay the terminal to the solution, a manual interactive judge
+	dumpCommandsFlag = false // --dump-commands: print compile/run command lines and exit
+	explainFlag      = false // --explain: describe what the run would do and exit
+
+	// Input handling
+	binaryInputFlag = false // feed stdin byte-for-byte, never treat input as text
+	expectedCmd     = ""    // --expected-cmd: sh command whose stdout on the input is the expected output
+	inputFilter     = ""    // --input-filter: shell command the input is piped through
+	inlineStdin     = ""    // --stdin: input text given on the command line, \n-escaped
+	genCmd          = ""    // --gen-cmd: sh command whose stdout is streamed to the solution
+
+	// Output handling
+	teeFlag         = false // also stream the solution's stdout to the console
+	keepOutputsFlag = false // multi-test runs: keep each output as build/<test>.actual
+	maxLineLength   = 0     // --max-line-length: warn about output lines longer than this, 0 = off
+	outputFilter    = ""    // --output-filter: shell command the actual output is piped through before comparing
+	saveExpected    = ""    // --save-expected: write the normalised output here instead of comparing
+	runName         = ""    // --name: label printed before the run and put in the reports
+
+	// Diff rendering
+	colorTheme         = diffThemes["dark"] // --color-theme dark | light | none
+	maxDiffLines       = 100                // --max-lines: diff table row cap, 0 = unlimited
+	maxColorRows       = 0                  // --max-color-rows: colour only the first N differing rows, 0 = all
+	diffGridFlag       = false              // align numeric grids and highlight differing cells
+	showWhitespaceFlag = false              // mark trailing whitespace and line ends in the diff
+	forceDiffFlag      = false              // diff large outputs even when their sizes differ
+	diffVerticalFlag   = false              // --diff-side vertical: expected above actual instead of side by side
+	diffJSONFlag       = false              // --diff-json: print the diff as JSON line objects instead of a table
+	pagerFlag          = false              // --pager: show a diff taller than the terminal through $PAGER
+
+	// Timing
+	warmupFlag            = false       // run once untimed before the measured run
+	timeLimit             time.Duration // --time-limit: kill the run and report TLE, 0 = none
+	cpuTimeout            time.Duration // --cpu-timeout: Linux, CPU time limit via RLIMIT_CPU → TLE, 0 = none
+	memoryLimit           int64         // --memory-limit in bytes: peak RSS above it is MLE, 0 = none
+	limitsFromHeaderFlag  = false       // read time/memory limits from the source's header comment
+	subtractJVMWarmupFlag = false       // Java: subtract a bare JVM startup from run times
+	benchOther            = ""          // --bench: time the source against this one
+	benchRuns             = 5           // --bench-runs: timed runs per solution
+	cpuCore               = -1          // --cpu: Linux, pin the solution to this CPU, -1 = off
+	timeFormat            = "auto"      // --time-format: ms, s or auto, how durations are printed
+
+	// Verdict rules
+	requireExitZeroFlag = false // nonzero exit code downgrades a run to RE
+	expectedExitCode    = -1    // --expected-exit-code: any other exit code is RE, -1 = off
+	detectPEFlag        = false // report PE instead of WA when only formatting differs
+	failOnStderrFlag    = false // any stderr output turns an accepted run into RE
+
+	// Failure reporting
+	showInputFlag   = false // echo the test input on WA/RE
+	showInputLines  = 20    // max input lines echoed by --show-input
+	captureCoreFlag = false // Linux: let crashes dump core and report where it went
+	failMessage     = ""    // --fail-message: line printed for every non-AC verdict, see expandFailMessage
+
+	// Machine-readable output
+	progressJSONFlag = false // NDJSON test_start / test_done events on stderr
+	jsonFlag         = false // --json: one JSON report of a multi-test run on stdout
+	junitPath        = ""    // --junit: write a JUnit XML report of a multi-test run here
+	badgeFormat      = ""    // "token" (--badge) or "shields" (--badge-url): one-line run summary
+
+	// CF API
+	cfMaxAttempts = 4 // --cf-max-attempts: tries per request on 429 / 5xx / call limit
 )
 
 func logVerbose(format string, args ...interface{}) {
 	if verboseFlag {
+		// --json owns stdout
+		if jsonFlag {
+			fmt.Fprintf(os.Stderr, "[verbose] "+format+"\n", args...)
+			return
+		}
 		fmt.Printf("[verbose] "+format+"\n", args...)
 	}
 }
 
+// logDuration reports how long cmd, started at start, took under
+// --show-command-duration.
+func logDuration(cmd *exec.Cmd, start time.Time) {
+	if !showDurationsFlag {
+		return
+	}
+	msg := fmt.Sprintf("[time] command %s took %s\n", shellJoin(cmd.Args), formatDuration(time.Since(start)))
+	if jsonFlag {
+		os.Stderr.WriteString(msg) // --json owns stdout
+		return
+	}
+	fmt.Print(msg)
+}
+
+// runTimed is cmd.Run for the untracked helper commands (filters,
+// --expected-cmd, make, git), logged like runChild.
+func runTimed(cmd *exec.Cmd) error {
+	defer logDuration(cmd, time.Now())
+	return cmd.Run()
+}
+
+// emitProgress writes one newline-delimited JSON event to stderr when
+// --progress-json is set, e.g.
+// {"event":"test_done","id":1,"ms":42,"name":"in.txt","verdict":"AC"}.
+// stdout is left untouched so the human-readable report is unaffected.
+func emitProgress(event map[string]interface{}) {
+	if !progressJSONFlag {
+		return
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(b))
+}
+
 // ── Language detection ────────────────────────────────────────────────────────
 
 func detectLang(sourceFile string) (string, error) {
@@ -40,103 +204,1318 @@ func detectLang(sourceFile string) (string, error) {
 		return "java", nil
 	case ".py":
 		return "python", nil
+	case ".sh":
+		return "bash", nil
+	case ".f90", ".f95", ".f":
+		return "fortran", nil
+	case ".d":
+		return "dlang", nil
+	case ".zig":
+		return "zig", nil
 	default:
 		return "", fmt.Errorf("unsupported extension: %s", filepath.Ext(sourceFile))
 	}
 }
 
+// runnerLangs lists every language compileAndRun knows how to build and run.
+var runnerLangs = map[string]string{
+	"go":      ".go",
+	"cpp":     ".cpp",
+	"rust":    ".rs",
+	"java":    ".java",
+	"python":  ".py",
+	"bash":    ".sh",
+	"fortran": ".f90",
+	"dlang":   ".d",
+	"zig":     ".zig",
+}
+
+// resolveLang returns the --lang override when set, otherwise the language
+// detected from the source file extension.
+func resolveLang(sourceFile string) (string, error) {
+	if langOverride == "" {
+		return detectLang(sourceFile)
+	}
+	if _, ok := runnerLangs[langOverride]; !ok {
+		known := make([]string, 0, len(runnerLangs))
+		for l := range runnerLangs {
+			known = append(known, l)
+		}
+		sort.Strings(known)
+		return "", fmt.Errorf("unknown --lang %q (known: %s)", langOverride, strings.Join(known, ", "))
+	}
+	return langOverride, nil
+}
+
 // ── Compile + run pipeline ────────────────────────────────────────────────────
 
-func compileAndRun(lang, sourceFile, inputFile, outputFile, expectedOutputFile string) error {
-	baseName := strings.TrimSuffix(filepath.Base(sourceFile), filepath.Ext(sourceFile))
-	buildDir := "build"
+// Solution is a source file that has been compiled (if needed) and is ready
+// to be executed against any number of tests.
+type Solution struct {
+	Lang     string
+	Source   string
+	BuildDir string
+	WorkDir  string   // directory the solution runs in
+	runArgs  []string // argv of one execution
+
+	jvmBaseline time.Duration // bare JVM startup, see jvmStartup
+	jvmMeasured bool
+}
+
+// command builds one execution of the solution, started in s.WorkDir.
+func (s *Solution) command(ctx context.Context) *exec.Cmd {
+	return toolCommandIn(ctx, s.WorkDir, localeEnv(), s.runArgs[0], s.runArgs[1:]...)
+}
+
+// localeEnv is the environment --locale sets for solution runs, so printf
+// and iostreams format numbers the same whatever the user's locale.
+func localeEnv() []string {
+	if runLocale == "inherit" {
+		return nil
+	}
+	return []string{"LC_ALL=" + runLocale, "LANG=" + runLocale}
+}
+
+// RunResult is the outcome of running a Solution on one test. Actual and
+// Expected hold the raw bytes so callers can compare or render them their own
+// way; nothing here is printed.
+type RunResult struct {
+	Name     string        // test label, e.g. the input file name
+	Verdict  string        // AC | WA | PE | RE | TLE | MLE
+	Elapsed  time.Duration // wall-clock run time
+	ExitCode int           // process exit code, -1 if killed by a signal
+	PeakMem  int64         // peak resident memory in bytes, 0 if unknown
+	Stderr   []byte        // program stderr, captured only with --sanitize / --fail-on-stderr
+	Actual   []byte        // solution stdout as written to the output file
+	Expected []byte        // contents of the expected file
+	Detail   string        // comparator explanation of a mismatch
+	RunErr   error         // execution error behind an RE verdict
+
+	TimeLimit   time.Duration // limits the run was judged by, see testCase.limits
+	MemoryLimit int64
+
+	runsSummary string // --runs: pass count and consistency across the runs
+}
+
+// compileAndRun builds sourceFile into buildDir, runs it once on inputFile
+// and prints the verdict (plus a diff on mismatch).
+func compileAndRun(lang, sourceFile, inputFile, outputFile, expectedOutputFile, buildDir string) error {
+	printRunName()
+	if cleanupFlag {
+		removeOnInterrupt(buildDir)
+	}
+	if limitsFromHeaderFlag {
+		if err := applyHeaderLimits(lang, sourceFile); err != nil {
+			return err
+		}
+	}
+	if dockerImage != "" {
+		if err := dockerMount(sourceFile, inputFile, expectedOutputFile, buildDir); err != nil {
+			return err
+		}
+	}
+	sol, err := buildSolution(lang, sourceFile, buildDir)
+	if err != nil {
+		return err
+	}
+	if warmupFlag {
+		sol.warmup(inputFile)
+	}
+	tc := testCase{Name: filepath.Base(inputFile), Input: inputFile, Expected: expectedOutputFile}
+	if genCmd != "" {
+		tc.Name = "gen-cmd"
+	}
+	var res *RunResult
+	if runsCount > 1 {
+		res, err = sol.repeatRuns(tc, outputFile)
+	} else {
+		res, err = sol.runTest(1, tc, outputFile)
+	}
+	if err != nil {
+		return err
+	}
+	reportResult(res, inputFile)
+	if runsCount > 1 {
+		fmt.Println(res.runsSummary)
+	}
+	if minimizeFlag && res.Verdict != "AC" {
+		path, err := sol.minimize(tc, outputFile, res.Verdict)
+		if err != nil {
+			return fmt.Errorf("--minimize: %w", err)
+		}
+		printInput(path)
+	}
+	if badgeFormat != "" {
+		printBadge(res.Verdict, res.Elapsed, lang)
+	}
+	switch res.Verdict {
+	case "RE":
+		if res.RunErr == nil {
+			return fmt.Errorf("execution failed: %s", res.Detail)
+		}
+		return fmt.Errorf("execution failed: %w", res.RunErr)
+	case "TLE":
+		if res.Detail != "" {
+			return fmt.Errorf("CPU time limit exceeded (%s)", cpuTimeout)
+		}
+		return fmt.Errorf("time limit exceeded (%s)", timeLimit)
+	case "MLE":
+		return fmt.Errorf("memory limit exceeded (%d MB)", memoryLimit>>20)
+	}
+	if saveExpected != "" {
+		if err := saveGolden(res.Actual); err != nil {
+			return err
+		}
+	}
 
-	if err := os.MkdirAll(buildDir, os.ModePerm); err != nil {
-		return fmt.Errorf("create build dir: %w", err)
+	if cleanupFlag {
+		os.RemoveAll(sol.BuildDir)
 	}
+	return nil
+}
+
+// saveGolden is --save-expected: it writes the run's output, normalised the
+// way comparisons see it, to the golden file later runs compare against.
+func saveGolden(actual []byte) error {
+	golden := normalizeOutput(actual)
+	if len(golden) > 0 {
+		golden = append(golden, '\n')
+	}
+	if err := os.WriteFile(saveExpected, golden, 0o644); err != nil {
+		return fmt.Errorf("--save-expected: %w", err)
+	}
+	fmt.Printf("── saved expected output to %s (%d bytes)\n", saveExpected, len(golden))
+	return nil
+}
+
+// buildPlan is the resolved command lines for one source file, worked out
+// without touching the filesystem.
+type buildPlan struct {
+	lang     string
+	source   string   // file handed to the compiler / interpreter
+	copyFrom string   // original source when it must first be copied to source
+	pch      bool     // precompiled header must be prepared before compiling
+	compile  []string // compiler argv, nil when there is no compile step
+	run      []string // argv of one execution
+}
+
+// repeatRuns is --runs: it runs tc runsCount times and returns the first
+// failing run (or the last one if all pass), with a summary of how many runs
+// passed and whether the output or verdict changed between runs — a strong
+// hint at undefined behaviour such as uninitialised memory.
+func (s *Solution) repeatRuns(tc testCase, outputFile string) (*RunResult, error) {
+	var chosen *RunResult
+	passed := 0
+	outputs, verdicts := map[string]bool{}, map[string]bool{}
+	for i := 1; i <= runsCount; i++ {
+		res, err := s.runTest(i, tc, outputFile)
+		if err != nil {
+			return nil, err
+		}
+		out, err := os.ReadFile(outputFile)
+		if err != nil {
+			return nil, fmt.Errorf("read output: %w", err)
+		}
+		outputs[string(out)], verdicts[res.Verdict] = true, true
+		if res.Verdict == "AC" {
+			passed++
+		}
+		if chosen == nil || chosen.Verdict == "AC" {
+			chosen = res
+		}
+	}
+	chosen.runsSummary = fmt.Sprintf("── %d/%d runs AC", passed, runsCount)
+	if len(outputs) > 1 || len(verdicts) > 1 {
+		chosen.runsSummary += fmt.Sprintf("  ⚠  nondeterministic: %d distinct outputs, %d distinct verdicts (undefined behaviour?)",
+			len(outputs), len(verdicts))
+	} else {
+		chosen.runsSummary += ", output identical every run"
+	}
+	return chosen, nil
+}
+
+// planBuild works out how lang compiles sourceFile into buildDir and how the
+// result is executed.
+func planBuild(lang, sourceFile, buildDir string) (*buildPlan, error) {
+	baseName := strings.TrimSuffix(filepath.Base(sourceFile), filepath.Ext(sourceFile))
+	plan := &buildPlan{lang: lang, source: sourceFile}
 
-	var execPath string
-	var compileCmd *exec.Cmd
+	// go, javac and zig insist on their own extension; with --lang a file like
+	// sol.txt is copied into the build dir under the expected name first.
+	if want := runnerLangs[lang]; (lang == "go" || lang == "java" || lang == "zig") && filepath.Ext(sourceFile) != want {
+		plan.copyFrom = sourceFile
+		plan.source = filepath.Join(buildDir, baseName+want)
+	}
+	src := plan.source
+	execPath := filepath.Join(buildDir, baseName)
 
 	switch lang {
 	case "go":
-		execPath = filepath.Join(buildDir, baseName)
-		compileCmd = exec.Command("go", "build", "-o", execPath, sourceFile)
+		plan.compile = []string{toolFor("go", "go"), "build", "-o", execPath}
+		if goTags != "" {
+			plan.compile = append(plan.compile, "-tags", goTags)
+		}
+		if goLDFlags != "" {
+			plan.compile = append(plan.compile, "-ldflags", goLDFlags)
+		}
+		plan.compile = append(plan.compile, src)
+		plan.run = []string{execPath}
 	case "cpp":
-		execPath = filepath.Join(buildDir, baseName)
-		compileCmd = exec.Command("g++", "-O2", "-std=c++23", "-o", execPath, sourceFile)
+		args := append([]string{toolFor("cpp", "g++")}, cppFlags...)
+		for _, dir := range cppIncludes {
+			args = append(args, "-I"+dir)
+		}
+		if pchFlag {
+			plan.pch = true
+			args = append(args, "-include", pchHeader(buildDir))
+		}
+		// -x c++ so g++ accepts nonstandard extensions forced via --lang
+		args = append(args, sanitizerFlags()...)
+		plan.compile = append(args, "-o", execPath, "-x", "c++", src)
+		// libraries must follow the source on the link line; -x none ends -x c++
+		if len(cppLibs) > 0 {
+			plan.compile = append(append(plan.compile, "-x", "none"), cppLibs...)
+		}
+		plan.run = []string{execPath}
 	case "rust":
-		execPath = filepath.Join(buildDir, baseName)
-		compileCmd = exec.Command("rustc", "-O", "-o", execPath, sourceFile)
+		plan.compile = []string{toolFor("rust", "rustc"), "-O", "-o", execPath, src}
+		plan.run = []string{execPath}
+		if manifest := findCargoManifest(src); manifest != "" {
+			// Cargo project: target dir lives under build/ so --cleanup removes it.
+			pkg, err := cargoPackageName(manifest)
+			if err != nil {
+				return nil, err
+			}
+			targetDir := filepath.Join(buildDir, "target")
+			plan.compile = []string{toolFor("cargo", "cargo"), "build", "--release",
+				"--manifest-path", manifest, "--target-dir", targetDir}
+			if compileJobs > 0 {
+				plan.compile = append(plan.compile, "-j", strconv.Itoa(compileJobs))
+			}
+			plan.run = []string{filepath.Join(targetDir, "release", pkg)}
+		}
+	case "fortran":
+		// -J keeps generated .mod files inside buildDir so --cleanup removes them
+		plan.compile = []string{toolFor("fortran", "gfortran"), "-O2", "-J" + buildDir, "-o", execPath, src}
+		plan.run = []string{execPath}
+	case "dlang":
+		// -od keeps the object file in buildDir instead of next to the source
+		switch dCompiler {
+		case "ldc2":
+			plan.compile = []string{toolFor("dlang", "ldc2"), "-O", "-of=" + execPath, "-od=" + buildDir, src}
+		default:
+			plan.compile = []string{toolFor("dlang", "dmd"), "-O", "-of" + execPath, "-od" + buildDir, src}
+		}
+		plan.run = []string{execPath}
+	case "zig":
+		// zig caches build artifacts next to the source unless told otherwise;
+		// inside buildDir --cleanup removes them
+		plan.compile = []string{toolFor("zig", "zig"), "build-exe", "-O", "ReleaseFast",
+			"--cache-dir", filepath.Join(buildDir, "zig-cache"), "-femit-bin=" + execPath, src}
+		plan.run = []string{execPath}
 	case "java":
-		compileCmd = exec.Command("javac", "-d", buildDir, sourceFile)
-		execPath = "java"
+		plan.compile = []string{javacTool(), "-d", buildDir, src}
+		plan.run = []string{toolFor("java", "java"), "-cp", buildDir, baseName}
 	case "python":
-		// no compile step
+		plan.run = []string{toolFor("python", "python3"), src}
+	case "bash":
+		plan.run = []string{shellBin, src}
 	default:
-		return fmt.Errorf("unsupported language: %s", lang)
+		return nil, fmt.Errorf("unsupported language: %s", lang)
 	}
+	return plan, nil
+}
+
+// toolchainKeys are the --toolchain keys and the commands they replace.
+var toolchainKeys = map[string]string{
+	"cpp": "g++", "go": "go", "rust": "rustc", "cargo": "cargo", "fortran": "gfortran",
+	"dlang": "dmd / ldc2", "zig": "zig", "java": "java", "javac": "javac", "python": "python3",
+}
 
-	if compileCmd != nil {
+// toolFor returns the command for a toolchain key: the --toolchain path if
+// one was given, def otherwise.
+func toolFor(key, def string) string {
+	if path, ok := toolchain[key]; ok {
+		return path
+	}
+	return def
+}
+
+// javacTool is the Java compiler: --toolchain javac=, else the javac next to
+// a --toolchain java= binary so both come from the same JDK, else javac.
+func javacTool() string {
+	if path, ok := toolchain["javac"]; ok {
+		return path
+	}
+	if java, ok := toolchain["java"]; ok && strings.ContainsRune(java, filepath.Separator) {
+		return filepath.Join(filepath.Dir(java), "javac")
+	}
+	return "javac"
+}
+
+// buildSolution runs the compile step for lang into buildDir and returns
+// the Solution ready to execute.
+func buildSolution(lang, sourceFile, buildDir string) (*Solution, error) {
+	return buildSolutionTo(lang, sourceFile, buildDir, nil)
+}
+
+// buildSolutionTo is buildSolution with the compiler output written to out
+// instead of the terminal, or to the terminal if out is nil.
+func buildSolutionTo(lang, sourceFile, buildDir string, out io.Writer) (*Solution, error) {
+	plan, err := planBuild(lang, sourceFile, buildDir)
+	if err != nil {
+		return nil, err
+	}
+	if compileMemoryLimit > 0 && dockerImage != "" {
+		return nil, fmt.Errorf("--compile-memory-limit cannot limit a compiler inside --docker")
+	}
+	if captureCoreFlag && dockerImage != "" {
+		return nil, fmt.Errorf("--capture-core cannot reach a solution inside --docker")
+	}
+	if cpuTimeout > 0 && dockerImage != "" {
+		return nil, fmt.Errorf("--cpu-timeout cannot limit a solution inside --docker")
+	}
+	if cpuCore >= 0 && dockerImage != "" {
+		return nil, fmt.Errorf("--cpu cannot pin a solution inside --docker (use docker's --cpuset-cpus)")
+	}
+
+	// 0755 / 0644 for everything we create; the process umask still applies.
+	if err := os.MkdirAll(buildDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create build dir: %w", err)
+	}
+	if plan.copyFrom != "" {
+		data, err := os.ReadFile(plan.copyFrom)
+		if err != nil {
+			return nil, fmt.Errorf("read source: %w", err)
+		}
+		if err := os.WriteFile(plan.source, data, 0o644); err != nil {
+			return nil, fmt.Errorf("copy source: %w", err)
+		}
+	}
+	key, cacheable := "", false
+	if cacheDir != "" {
+		if err := checkCacheDir(buildDir); err != nil {
+			return nil, err
+		}
+		if key, cacheable, err = cacheKey(plan, buildDir); err != nil {
+			return nil, err
+		}
+	}
+	if cacheable {
+		hit, err := restoreCached(key, plan.run[0])
+		if err != nil {
+			return nil, err
+		}
+		if hit {
+			logVerbose("compile: cached %s", filepath.Join(cacheDir, key))
+			plan.pch, plan.compile, cacheable = false, nil, false
+		}
+	}
+	if plan.pch {
+		if err := preparePCH(buildDir, out); err != nil {
+			return nil, err
+		}
+	}
+	if lang == "bash" && dockerImage == "" {
+		// no compile step, but fail early if the interpreter is missing
+		if _, err := exec.LookPath(shellBin); err != nil {
+			return nil, fmt.Errorf("shell interpreter %q not found: %w", shellBin, err)
+		}
+	}
+
+	if plan.compile != nil {
+		// The compiler is killed when --compile-timeout expires.
+		ctx := context.Background()
+		if compileTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, compileTimeout)
+			defer cancel()
+		}
+		compileCmd := toolCommand(ctx, plan.compile[0], plan.compile[1:]...)
 		logVerbose("compile: %s", strings.Join(compileCmd.Args, " "))
-		compileCmd.Stdout = os.Stdout
-		compileCmd.Stderr = os.Stderr
-		if err := compileCmd.Run(); err != nil {
-			return fmt.Errorf("compilation failed: %w", err)
+		if err := runCompiler(compileCmd, out); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, fmt.Errorf("Compilation Timed Out (compiler killed after %s)", compileTimeout)
+			}
+			return nil, fmt.Errorf("compilation failed: %w", err)
+		}
+	}
+	if cacheable {
+		if err := storeCached(key, plan.run[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := solutionWorkDir(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	run, err := absRunArgs(plan, buildDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Solution{Lang: lang, Source: plan.source, BuildDir: buildDir, WorkDir: dir, runArgs: run}, nil
+}
+
+// sourceFromStdin saves a source piped in as "-" to buildDir/solution<ext>.
+// There is no extension to detect, so --lang is required. The solution
+// then runs in the current directory unless --workdir says otherwise.
+func sourceFromStdin(buildDir string) (string, error) {
+	if langOverride == "" {
+		return "", fmt.Errorf("source \"-\" (stdin) needs --lang")
+	}
+	ext, ok := runnerLangs[langOverride]
+	if !ok {
+		return resolveLang("-") // reports the unknown --lang
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("read source from stdin: %w", err)
+	}
+	if err := os.MkdirAll(buildDir, 0o755); err != nil {
+		return "", fmt.Errorf("create build dir: %w", err)
+	}
+	path := filepath.Join(buildDir, "solution"+ext)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write source: %w", err)
+	}
+	if workDir == "" {
+		workDir = "."
+	}
+	logVerbose("source: %d bytes from stdin → %s", len(data), path)
+	return path, nil
+}
+
+// writeInlineStdin saves the --stdin text to buildDir/stdin.txt, turning the
+// escapes \n, \t and \\ into the real characters and ending it with a
+// newline, and returns the path to use as the input file. With --binary the
+// text is written exactly as given.
+func writeInlineStdin(buildDir string) (string, error) {
+	text := inlineStdin
+	if !binaryInputFlag {
+		text = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t").Replace(text)
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+	}
+	if err := os.MkdirAll(buildDir, 0o755); err != nil {
+		return "", fmt.Errorf("create build dir: %w", err)
+	}
+	path := filepath.Join(buildDir, "stdin.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		return "", fmt.Errorf("write --stdin input: %w", err)
+	}
+	return path, nil
+}
+
+// solutionWorkDir is --workdir, or the directory of sourceFile by default so
+// relative file reads in the solution resolve next to the source.
+func solutionWorkDir(sourceFile string) (string, error) {
+	dir := workDir
+	if dir == "" {
+		dir = filepath.Dir(sourceFile)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("--workdir: %w", err)
+	}
+	if fi, err := os.Stat(abs); err != nil || !fi.IsDir() {
+		return "", fmt.Errorf("--workdir: %s is not a directory", dir)
+	}
+	return abs, nil
+}
+
+// absRunArgs makes the build-dir and source paths in plan.run absolute, as
+// the solution runs in a different directory than cfr.
+func absRunArgs(plan *buildPlan, buildDir string) ([]string, error) {
+	run := append([]string{}, plan.run...)
+	for i, a := range run {
+		if a == plan.source || a == buildDir || strings.HasPrefix(a, buildDir+string(filepath.Separator)) {
+			abs, err := filepath.Abs(a)
+			if err != nil {
+				return nil, err
+			}
+			run[i] = abs
+		}
+	}
+	return run, nil
+}
+
+// runCompiler runs a compile step with its output on the terminal, or in out
+// if that is not nil. With --no-compile-output the terminal output is
+// buffered and only shown if it fails.
+func runCompiler(cmd *exec.Cmd, out io.Writer) error {
+	defer untrackChild(cmd)
+	defer logDuration(cmd, time.Now())
+	var buf, diag bytes.Buffer
+	if out != nil {
+		cmd.Stdout = out
+		cmd.Stderr = out
+	} else if noCompileOutputFlag {
+		cmd.Stdout = &buf
+		cmd.Stderr = &buf
+	} else {
+		cmd.Stdout = os.Stdout
+		if jsonFlag {
+			cmd.Stdout = os.Stderr
+		}
+		cmd.Stderr = os.Stderr
+	}
+	if compileMemoryLimit > 0 {
+		// keep a copy of the diagnostics to recognise allocation failures
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, &diag)
+	}
+	if err := startChild(cmd); err != nil {
+		return err
+	}
+	if compileMemoryLimit > 0 {
+		if err := limitAddressSpace(cmd.Process.Pid, compileMemoryLimit); err != nil {
+			killProcessGroup(cmd)
+			cmd.Wait()
+			return fmt.Errorf("--compile-memory-limit: %w", err)
+		}
+	}
+	err := cmd.Wait()
+	holdIfInterrupted()
+	if err != nil {
+		os.Stderr.Write(buf.Bytes())
+		if compileMemoryLimit > 0 && hitMemoryLimit(cmd.ProcessState, diag.String()) {
+			return fmt.Errorf("compiler exceeded memory limit (%d MB)", compileMemoryLimit>>20)
+		}
+	}
+	return err
+}
+
+// hitMemoryLimit guesses whether a failed compile ran into the address-space
+// limit: the compiler was killed by a signal, its peak memory got close to
+// the limit, or its diagnostics report a failed allocation or a crashed
+// compiler proper (cc1plus dies with SIGSEGV when mmap fails).
+func hitMemoryLimit(ps *os.ProcessState, diagnostics string) bool {
+	if ps == nil {
+		return false
+	}
+	if ps.ExitCode() == -1 || peakMemory(ps) >= compileMemoryLimit/2 {
+		return true
+	}
+	for _, sign := range []string{"memory exhausted", "out of memory", "Cannot allocate memory", "bad_alloc", "signal terminated program"} {
+		if strings.Contains(diagnostics, sign) {
+			return true
 		}
 	}
+	return false
+}
+
+// dumpCommands prints the shell-quoted compile and run command lines for
+// sourceFile without executing anything (--dump-commands).
+func dumpCommands(lang, sourceFile, inputFile, outputFile string) error {
+	if dockerImage != "" {
+		if err := dockerMount(sourceFile, inputFile, outputFile); err != nil {
+			return err
+		}
+	}
+	plan, err := planBuild(lang, sourceFile, "build")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if plan.copyFrom != "" {
+		fmt.Printf("# copy\ncp %s %s\n", shellQuote(plan.copyFrom), shellQuote(plan.source))
+	}
+	if plan.pch {
+		fmt.Printf("# precompile header\n%s\n", shellJoin(toolCommand(ctx, toolFor("cpp", "g++"), pchCommand("build")...).Args))
+	}
+	if plan.compile != nil {
+		fmt.Printf("# compile\n%s\n", shellJoin(toolCommand(ctx, plan.compile[0], plan.compile[1:]...).Args))
+	}
+	dir, err := solutionWorkDir(sourceFile)
+	if err != nil {
+		return err
+	}
+	runArgs, err := absRunArgs(plan, "build")
+	if err != nil {
+		return err
+	}
+	run := shellJoin(toolCommandIn(ctx, dir, localeEnv(), runArgs[0], runArgs[1:]...).Args)
+	if env := localeEnv(); env != nil && dockerImage == "" {
+		run = strings.Join(env, " ") + " " + run
+	}
+	if cwd, _ := os.Getwd(); dir != cwd && dockerImage == "" {
+		run = "(cd " + shellQuote(dir) + " && " + run + ")"
+	}
+	if inputFilter != "" {
+		run = inputFilter + " < " + shellQuote(inputFile) + " | " + run
+	} else {
+		run += " < " + shellQuote(inputFile)
+	}
+	fmt.Printf("# run\n%s > %s\n", run, shellQuote(outputFile))
+	return nil
+}
+
+// shellQuote quotes s for a POSIX shell unless it only has safe characters.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
 
-	inFile, err := os.Open(inputFile)
+// runTest executes the solution once with tc.Input on stdin, writes stdout
+// to outputFile and compares it against tc.Expected. id numbers the test in
+// --progress-json events.
+func (s *Solution) runTest(id int, tc testCase, outputFile string) (res *RunResult, err error) {
+	inFile, err := openInput(tc.Input)
 	if err != nil {
-		return fmt.Errorf("open input: %w", err)
+		return nil, err
 	}
 	defer inFile.Close()
 
-	outFile, err := os.Create(outputFile)
+	outFile, err := os.OpenFile(outputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
 	if err != nil {
-		return fmt.Errorf("create output: %w", err)
+		return nil, fmt.Errorf("create output: %w", err)
 	}
 	defer outFile.Close()
 
-	var runCmd *exec.Cmd
-	switch lang {
-	case "go", "cpp", "rust":
-		runCmd = exec.Command(execPath)
-	case "java":
-		runCmd = exec.Command("java", "-cp", buildDir, baseName)
-	case "python":
-		runCmd = exec.Command("python3", sourceFile)
+	// The solution is killed when --time-limit expires.
+	ctx := context.Background()
+	tl, ml := tc.limits()
+	if tl > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tl)
+		defer cancel()
 	}
+	runCmd := s.command(ctx)
 	logVerbose("run: %s", strings.Join(runCmd.Args, " "))
-	runCmd.Stdin = inFile
+	runCmd.Stdin = stdinOf(inFile)
 	runCmd.Stdout = outFile
+	if teeFlag {
+		console := os.Stdout
+		if jsonFlag {
+			console = os.Stderr
+		}
+		runCmd.Stdout = io.MultiWriter(outFile, console)
+	}
 	runCmd.Stderr = os.Stderr
-	if err := runCmd.Run(); err != nil {
-		return fmt.Errorf("execution failed: %w", err)
+	var stderr bytes.Buffer
+	if len(sanitizers) > 0 && s.Lang == "cpp" || failOnStderrFlag {
+		runCmd.Stderr = &stderr // shown with the verdict, see reportResult
+	}
+
+	// Results are labelled with the test name, e.g. "sample-2.in: AC".
+	res = &RunResult{Name: tc.Name, TimeLimit: tl, MemoryLimit: ml}
+	emitProgress(map[string]interface{}{"event": "test_start", "id": id, "name": res.Name})
+	defer func() {
+		if err == nil { // every verdict, however runTest returns it
+			emitProgress(map[string]interface{}{"event": "test_done", "id": id, "name": res.Name, "verdict": res.Verdict, "ms": res.Elapsed.Milliseconds()})
+		}
+	}()
+	start := time.Now()
+	if res.RunErr, err = runSolution(runCmd); err != nil {
+		return nil, err
+	}
+	res.Elapsed = time.Since(start)
+	if subtractJVMWarmupFlag && s.Lang == "java" {
+		res.Elapsed -= min(s.jvmStartup(), res.Elapsed)
+	}
+	var cpu time.Duration
+	if ps := runCmd.ProcessState; ps != nil {
+		res.ExitCode = ps.ExitCode()
+		res.PeakMem = peakMemory(ps)
+		cpu = ps.UserTime() + ps.SystemTime()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		res.Verdict = "TLE"
+		return res, nil
+	}
+	// RLIMIT_CPU only has whole seconds, so the finer limit is checked here;
+	// a run killed by SIGXCPU is always over it.
+	if cpuTimeout > 0 && cpu > cpuTimeout {
+		res.Verdict = "TLE"
+		res.Detail = fmt.Sprintf("CPU time %d ms, --cpu-timeout %s", cpu.Milliseconds(), cpuTimeout)
+		return res, nil
+	}
+	if ml > 0 && res.PeakMem > ml {
+		res.Verdict = "MLE"
+		return res, nil
+	}
+	res.Stderr = stderr.Bytes()
+	// A plain nonzero exit still gets its output compared; crashes,
+	// sanitizer reports and failures to start are RE straight away.
+	var exitErr *exec.ExitError
+	if report := sanitizerReport(res.Stderr); report != "" {
+		res.Verdict = "RE"
+		res.Detail = report + " (report below)"
+		return res, nil
+	}
+	if res.RunErr != nil && !(errors.As(res.RunErr, &exitErr) && res.ExitCode > 0) {
+		res.Verdict = "RE"
+		if captureCoreFlag && runCmd.ProcessState != nil {
+			res.Detail = coreDumpReport(runCmd.ProcessState, runCmd.Dir)
+		}
+		return res, nil
+	}
+
+	if res.Actual, err = os.ReadFile(outputFile); err != nil {
+		return nil, fmt.Errorf("read output: %w", err)
+	}
+	if outputFilter != "" {
+		if res.Actual, err = filterOutput(res.Actual); err != nil {
+			return nil, err
+		}
+	}
+	if saveExpected != "" {
+		res.Expected = res.Actual // nothing to compare against, see saveGolden
+		res.Detail = "not compared, output saved as the expected file (--save-expected)"
+	} else if res.Expected, err = readExpected(tc); err != nil {
+		return nil, err
+	}
+
+	res.Verdict = "AC"
+	exp, act := normalizeOutput(res.Expected), normalizeOutput(res.Actual)
+	if ignoreLines != nil {
+		exp, act = dropMatchingLines(exp, ignoreLines), dropMatchingLines(act, ignoreLines)
+	}
+	if compareStream != nil {
+		exp, act = keepMatchingLines(exp, compareStream), keepMatchingLines(act, compareStream)
 	}
+	if ok, by, detail := compareOutputs(exp, act); ok {
+		if by != compareModes[0] {
+			res.Detail = "matched by --compare " + by
+		}
+	} else {
+		res.Verdict = "WA"
+		res.Detail = detail
+		// Same tokens but different spacing/newlines is a presentation error.
+		if detectPEFlag {
+			if ok, _ := compareTokens(exp, act); ok {
+				res.Verdict = "PE"
+				res.Detail = "tokens match; only whitespace or line breaks differ"
+			}
+		}
+	}
+	if expectedExitCode >= 0 {
+		if res.ExitCode != expectedExitCode {
+			res.Verdict = "RE"
+			res.Detail = fmt.Sprintf("exit code %d, expected %d", res.ExitCode, expectedExitCode)
+		}
+	} else if res.ExitCode != 0 && requireExitZeroFlag {
+		res.Verdict = "RE"
+	}
+	if failOnStderrFlag && len(res.Stderr) > 0 && (res.Verdict == "AC" || res.Verdict == "PE") {
+		res.Verdict = "RE"
+		res.Detail = fmt.Sprintf("wrote %d bytes to stderr (--fail-on-stderr)", len(res.Stderr))
+	}
+	return res, nil
+}
+
+// runSolution is runChild for executions of the solution: right after it
+// starts, the core size limit is raised for --capture-core, the process is
+// pinned to the --cpu core and its CPU time capped for --cpu-timeout.
+// runErr is the run's own error, err a failure to set any of them up.
+func runSolution(cmd *exec.Cmd) (runErr, err error) {
+	defer untrackChild(cmd)
+	defer logDuration(cmd, time.Now())
+	if err := startChild(cmd); err != nil {
+		return err, nil
+	}
+	if err := prepareSolution(cmd.Process.Pid); err != nil {
+		killProcessGroup(cmd)
+		cmd.Wait()
+		return nil, err
+	}
+	runErr = cmd.Wait()
+	holdIfInterrupted()
+	return runErr, nil
+}
 
-	actual, err := os.ReadFile(outputFile)
+// prepareSolution applies the per-process settings of runSolution to pid.
+func prepareSolution(pid int) error {
+	if captureCoreFlag {
+		if err := allowCoreDumps(pid); err != nil {
+			return fmt.Errorf("--capture-core: %w", err)
+		}
+	}
+	if cpuCore >= 0 {
+		if err := setCPUAffinity(pid, cpuCore); err != nil {
+			return fmt.Errorf("--cpu %d: %w", cpuCore, err)
+		}
+	}
+	if cpuTimeout > 0 {
+		if err := limitCPUTime(pid, cpuTimeout); err != nil {
+			return fmt.Errorf("--cpu-timeout: %w", err)
+		}
+	}
+	return nil
+}
+
+// generatedInput is the read end of the pipe --gen-cmd writes into. The
+// solution gets the *os.File itself as stdin, so the data never passes
+// through cfr.
+type generatedInput struct {
+	*os.File
+	done   chan error    // the generator's exit, see Close
+	stderr *bytes.Buffer // shown only if the generator failed on its own
+}
+
+// startGenerator runs --gen-cmd with its stdout connected to a pipe and
+// returns the read end; generator and solution then run concurrently.
+func startGenerator() (*generatedInput, error) {
+	r, w, err := os.Pipe()
 	if err != nil {
-		return fmt.Errorf("read output: %w", err)
+		return nil, fmt.Errorf("--gen-cmd: %w", err)
 	}
-	expected, err := os.ReadFile(expectedOutputFile)
+	cmd := exec.Command("sh", "-c", genCmd)
+	var stderr bytes.Buffer
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	logVerbose("gen-cmd: sh -c %q | solution", genCmd)
+	err = cmd.Start()
+	w.Close() // the generator holds the only write end, so EOF follows its exit
 	if err != nil {
-		return fmt.Errorf("read expected: %w", err)
+		r.Close()
+		return nil, fmt.Errorf("--gen-cmd %q: %w", genCmd, err)
 	}
+	g := &generatedInput{File: r, done: make(chan error, 1), stderr: &stderr}
+	start := time.Now()
+	go func() {
+		err := cmd.Wait()
+		logDuration(cmd, start)
+		g.done <- err
+	}()
+	return g, nil
+}
 
-	if !bytes.Equal(bytes.TrimSpace(actual), bytes.TrimSpace(expected)) {
-		fmt.Println("✗ Output differs:")
-		diffLines(string(expected), string(actual))
+// stdinOf is what to set as a child's Stdin for an opened input: the pipe
+// itself for --gen-cmd, so exec hands the fd over instead of copying.
+func stdinOf(in io.Reader) io.Reader {
+	if g, ok := in.(*generatedInput); ok {
+		return g.File
+	}
+	return in
+}
+
+// Close closes the read end and waits for the generator. A solution that
+// stopped reading early leaves the generator blocked on a full pipe; closing
+// the last read end makes its next write fail with EPIPE, so it exits
+// instead of deadlocking. Only a generator that failed on its own, before
+// that, is reported.
+func (g *generatedInput) Close() error {
+	select {
+	case err := <-g.done:
+		g.File.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠  --gen-cmd %q: %v\n", genCmd, err)
+			os.Stderr.Write(g.stderr.Bytes())
+		}
+		return err
+	default:
+	}
+	g.File.Close()
+	<-g.done // still writing: EPIPE / SIGPIPE from the close, not a failure
+	return nil
+}
+
+// openInput returns the solution's stdin for inputFile. The file is handed
+// to the child as-is — any input-side normalisation must be skipped when
+// --binary is set. With --input-filter the file is piped through the command
+// first and its stdout becomes the stdin; the file itself is never modified.
+func openInput(inputFile string) (io.ReadCloser, error) {
+	if genCmd != "" {
+		return startGenerator()
+	}
+	f, err := os.Open(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("open input: %w", err)
+	}
+	if inputFilter == "" {
+		return f, nil
+	}
+	defer f.Close()
+
+	var filtered, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", inputFilter)
+	cmd.Stdin = f
+	cmd.Stdout = &filtered
+	cmd.Stderr = &stderr
+	logVerbose("input-filter: sh -c %q < %s", inputFilter, inputFile)
+	if err := runTimed(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("input filter %q: %w: %s", inputFilter, err, msg)
+		}
+		return nil, fmt.Errorf("input filter %q: %w", inputFilter, err)
+	}
+	return io.NopCloser(bytes.NewReader(filtered.Bytes())), nil
+}
+
+// jvmStartup measures a bare JVM start (`java -version`, best of three) for
+// --subtract-jvm-warmup; measured once per Solution.
+func (s *Solution) jvmStartup() time.Duration {
+	if s.jvmMeasured {
+		return s.jvmBaseline
+	}
+	s.jvmMeasured = true
+	for i := 0; i < 3; i++ {
+		cmd := toolCommand(context.Background(), toolFor("java", "java"), "-version")
+		start := time.Now()
+		if err := runChild(cmd); err != nil {
+			logVerbose("jvm baseline: %v", err)
+			s.jvmBaseline = 0
+			return 0
+		}
+		if d := time.Since(start); i == 0 || d < s.jvmBaseline {
+			s.jvmBaseline = d
+		}
+	}
+	logVerbose("jvm baseline: %d ms subtracted from each run", s.jvmBaseline.Milliseconds())
+	return s.jvmBaseline
+}
+
+// filterOutput pipes the actual output through --output-filter. The result
+// is what gets compared and diffed; the output file keeps the raw output.
+func filterOutput(actual []byte) ([]byte, error) {
+	var filtered, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", outputFilter)
+	cmd.Stdin = bytes.NewReader(actual)
+	cmd.Stdout = &filtered
+	cmd.Stderr = &stderr
+	logVerbose("output-filter: sh -c %q", outputFilter)
+	if err := runTimed(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("output filter %q: %w: %s", outputFilter, err, msg)
+		}
+		return nil, fmt.Errorf("output filter %q: %w", outputFilter, err)
+	}
+	return filtered.Bytes(), nil
+}
+
+// readExpected returns the expected output of tc: the stdout of
+// --expected-cmd run on the input when set, else the expected file.
+func readExpected(tc testCase) ([]byte, error) {
+	if expectedCmd == "" {
+		data, err := os.ReadFile(tc.Expected)
+		if err != nil {
+			return nil, fmt.Errorf("read expected: %w", err)
+		}
+		return data, nil
+	}
+	in, err := openInput(tc.Input)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", expectedCmd)
+	cmd.Stdin = in
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	logVerbose("expected-cmd: sh -c %q < %s", expectedCmd, tc.Input)
+	if err := runTimed(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("expected command %q: %w: %s", expectedCmd, err, msg)
+		}
+		return nil, fmt.Errorf("expected command %q: %w", expectedCmd, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// sanitizerReport names the sanitizer that reported an error in stderr, or
+// returns "" if there is none.
+func sanitizerReport(stderr []byte) string {
+	for _, sig := range []struct{ marker, name string }{
+		{"ERROR: AddressSanitizer", "AddressSanitizer"},
+		{"ERROR: LeakSanitizer", "LeakSanitizer"},
+		{"runtime error:", "UndefinedBehaviorSanitizer"},
+	} {
+		if bytes.Contains(stderr, []byte(sig.marker)) {
+			return sig.name
+		}
+	}
+	return ""
+}
+
+// warmup runs the solution once on inputFile with its output discarded, so
+// the measured run that follows doesn't pay for cold page and disk caches.
+// Failures are ignored here; the measured run reports them.
+func (s *Solution) warmup(inputFile string) {
+	inFile, err := openInput(inputFile)
+	if err != nil {
+		return
+	}
+	defer inFile.Close()
+
+	cmd := s.command(context.Background())
+	cmd.Stdin = stdinOf(inFile)
+	cmd.Stdout = io.Discard
+	cmd.Stderr = io.Discard
+	start := time.Now()
+	err, _ = runSolution(cmd)
+	logVerbose("warmup: %s (%d ms, err=%v)", strings.Join(cmd.Args, " "), time.Since(start).Milliseconds(), err)
+}
+
+// formatDuration renders a run or compile time for the text output, in the
+// --time-format unit. auto uses ms below a second and seconds above.
+func formatDuration(d time.Duration) string {
+	switch {
+	case timeFormat == "ms", timeFormat == "auto" && d < time.Second:
+		return fmt.Sprintf("%d ms", d.Milliseconds())
+	case timeFormat == "s":
+		return fmt.Sprintf("%.3f s", d.Seconds())
+	}
+	return fmt.Sprintf("%.2f s", d.Seconds())
+}
+
+// reportResult prints the verdict line for res, followed on failure by the
+// input (with --show-input) and the expected/actual diff.
+func reportResult(res *RunResult, inputFile string) {
+	switch res.Verdict {
+	case "AC":
+		fmt.Printf("✓ %s: AC (%s) — output matches expected\n", res.Name, formatDuration(res.Elapsed))
+		if res.Detail != "" {
+			fmt.Printf("  ↳ %s\n", res.Detail)
+		}
+	case "RE":
+		if res.ExitCode > 0 {
+			fmt.Printf("✗ %s: RE — exited with code %d\n", res.Name, res.ExitCode)
+		} else {
+			fmt.Printf("✗ %s: RE\n", res.Name)
+		}
+		if res.Detail != "" {
+			fmt.Printf("  ↳ %s\n", res.Detail)
+		}
+		if showInputFlag {
+			printInput(inputFile)
+		}
+	case "TLE":
+		switch {
+		case res.Detail != "":
+			fmt.Printf("✗ %s: TLE — %s\n", res.Name, res.Detail)
+		case cpuTimeout > 0:
+			fmt.Printf("✗ %s: TLE — killed after %s of wall-clock time\n", res.Name, res.TimeLimit)
+		default:
+			fmt.Printf("✗ %s: TLE — killed after %s\n", res.Name, res.TimeLimit)
+		}
+		if showInputFlag {
+			printInput(inputFile)
+		}
+	case "MLE":
+		fmt.Printf("✗ %s: MLE (%s) — peak memory %d MB, limit %d MB\n", res.Name, formatDuration(res.Elapsed), res.PeakMem>>20, res.MemoryLimit>>20)
+		if showInputFlag {
+			printInput(inputFile)
+		}
+	default:
+		fmt.Printf("✗ %s: %s (%s) — output differs:\n", res.Name, res.Verdict, formatDuration(res.Elapsed))
+		if res.Detail != "" {
+			fmt.Printf("  ↳ %s\n", res.Detail)
+		}
+		if showInputFlag {
+			printInput(inputFile)
+		}
+		renderDiff(string(streamLines(res.Expected)), string(streamLines(res.Actual)))
+	}
+	if len(res.Stderr) > 0 {
+		printStderr(res.Stderr)
+	}
+	if maxLineLength > 0 {
+		warnLongLines(res.Actual)
+	}
+	if (res.Verdict == "AC" || res.Verdict == "WA" || res.Verdict == "PE") && res.ExitCode != 0 && expectedExitCode < 0 {
+		fmt.Printf("  ⚠  exited with code %d (use --require-exit-zero to treat as RE)\n", res.ExitCode)
+	}
+	if failMessage != "" && res.Verdict != "AC" {
+		fmt.Println(expandFailMessage(res))
+	}
+}
+
+// expandFailMessage fills the --fail-message placeholders {verdict},
+// {test}, {time} and {exit} in for res.
+func expandFailMessage(res *RunResult) string {
+	return strings.NewReplacer(
+		"{verdict}", res.Verdict,
+		"{test}", res.Name,
+		"{time}", formatDuration(res.Elapsed),
+		"{exit}", strconv.Itoa(res.ExitCode),
+	).Replace(failMessage)
+}
+
+// printStderr shows the program's stderr captured under --sanitize or
+// --fail-on-stderr.
+func printStderr(stderr []byte) {
+	fmt.Println("┌─ program stderr")
+	for _, line := range strings.Split(strings.TrimRight(string(stderr), "\n"), "\n") {
+		fmt.Printf("│ %s\n", line)
+	}
+	fmt.Println("└────────────────────────────────────────────────────────────────────────────")
+}
+
+// printRunName prints the --name header that opens the output of a run.
+// Under --json the report carries the name instead.
+func printRunName() {
+	if runName != "" && !jsonFlag {
+		fmt.Printf("═══ %s ═══\n", runName)
+	}
+}
+
+// printBadge prints the one-line --badge summary of a run, e.g.
+// "AC|124ms|cpp", or a shields.io badge URL with --badge-url. It goes to
+// stderr under --json, which owns stdout.
+func printBadge(verdict string, elapsed time.Duration, lang string) {
+	line := fmt.Sprintf("%s|%dms|%s", verdict, elapsed.Milliseconds(), lang)
+	if badgeFormat == "shields" {
+		color := "red"
+		if verdict == "AC" {
+			color = "brightgreen"
+		}
+		// shields.io path syntax: "-" and "_" are doubled, a space is "_"
+		msg := strings.NewReplacer("-", "--", "_", "__", " ", "_").Replace(
+			fmt.Sprintf("%s %dms %s", verdict, elapsed.Milliseconds(), lang))
+		line = "https://img.shields.io/badge/cfr-" + url.PathEscape(msg) + "-" + color
+	}
+	if jsonFlag {
+		fmt.Fprintln(os.Stderr, line)
 	} else {
-		fmt.Println("✓ Output matches expected")
+		fmt.Println(line)
 	}
+}
 
-	if cleanupFlag {
-		os.RemoveAll(buildDir)
+// warnLongLines flags output lines longer than --max-line-length, usually a
+// sign of a missing newline or a runaway loop.
+func warnLongLines(out []byte) {
+	const show = 5
+	n := 0
+	for i, line := range strings.Split(string(out), "\n") {
+		if l := utf8.RuneCountInString(strings.TrimRight(line, "\r")); l > maxLineLength {
+			if n < show {
+				fmt.Printf("  ⚠  line %d is %d chars long (--max-line-length %d)\n", i+1, l, maxLineLength)
+			}
+			n++
+		}
+	}
+	if n > show {
+		fmt.Printf("  ⚠  … %d more lines over the limit\n", n-show)
+	}
+}
+
+// cppFlags are shared by solution and precompiled-header builds; a PCH is
+// only used by g++ when the flags match.
+var cppFlags = []string{"-O2", "-std=c++23"}
+
+// sanitizerFlags are the g++ flags for --sanitize, nil without it. The PCH
+// is built with them too, g++ refuses it otherwise.
+func sanitizerFlags() []string {
+	if len(sanitizers) == 0 {
+		return nil
+	}
+	// no recovery: UBSan aborts on the first error instead of carrying on
+	return []string{"-fsanitize=" + strings.Join(sanitizers, ","), "-fno-sanitize-recover=all",
+		"-fno-omit-frame-pointer", "-g"}
+}
+
+// pchHeader is the header passed via -include when --pch is set.
+func pchHeader(buildDir string) string {
+	return filepath.Join(buildDir, "pch", "stdc++.h")
+}
+
+// pchCommand is the g++ argv (without the compiler) that precompiles pchHeader.
+func pchCommand(buildDir string) []string {
+	header := pchHeader(buildDir)
+	args := append(append([]string{}, cppFlags...), sanitizerFlags()...)
+	return append(args, "-x", "c++-header", header, "-o", header+".gch")
+}
+
+// preparePCH precompiles <bits/stdc++.h> into build/pch for use via
+// -include pchHeader. The .gch is rebuilt whenever the `g++ --version`
+// output or the flags differ from the ones recorded next to it.
+func preparePCH(buildDir string, out io.Writer) error {
+	dir := filepath.Join(buildDir, "pch")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create pch dir: %w", err)
+	}
+	header := pchHeader(buildDir)
+	gch := header + ".gch"
+	stamp := filepath.Join(dir, "compiler.version")
+
+	var versionOut bytes.Buffer
+	versionCmd := toolCommand(context.Background(), toolFor("cpp", "g++"), "--version")
+	versionCmd.Stdout = &versionOut
+	if err := runChild(versionCmd); err != nil {
+		return fmt.Errorf("g++ --version: %w", err)
+	}
+	version := append(versionOut.Bytes(), strings.Join(pchCommand(buildDir), " ")+"\n"...)
+	if old, err := os.ReadFile(stamp); err == nil && bytes.Equal(old, version) {
+		if _, err := os.Stat(gch); err == nil {
+			logVerbose("pch: reusing %s", gch)
+			return nil
+		}
+	}
+
+	if err := os.WriteFile(header, []byte("#include <bits/stdc++.h>\n"), 0o644); err != nil {
+		return fmt.Errorf("write pch header: %w", err)
+	}
+	cmd := toolCommand(context.Background(), toolFor("cpp", "g++"), pchCommand(buildDir)...)
+	logVerbose("pch: %s", strings.Join(cmd.Args, " "))
+	if err := runCompiler(cmd, out); err != nil {
+		return fmt.Errorf("precompile header: %w", err)
+	}
+	if err := os.WriteFile(stamp, version, 0o644); err != nil {
+		return fmt.Errorf("write pch stamp: %w", err)
 	}
 	return nil
 }
 
+// findCargoManifest returns the Cargo.toml next to sourceFile (or one level
+// up for the usual src/main.rs layout), or "" for a standalone .rs file.
+func findCargoManifest(sourceFile string) string {
+	dir := filepath.Dir(sourceFile)
+	candidates := []string{filepath.Join(dir, "Cargo.toml")}
+	if filepath.Base(dir) == "src" {
+		candidates = append(candidates, filepath.Join(filepath.Dir(dir), "Cargo.toml"))
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+	return ""
+}
+
+// cargoPackageName reads the [package] name from a Cargo.toml, which is the
+// name of the binary cargo produces.
+func cargoPackageName(manifest string) (string, error) {
+	f, err := os.Open(manifest)
+	if err != nil {
+		return "", fmt.Errorf("open %s: %w", manifest, err)
+	}
+	defer f.Close()
+
+	section := ""
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "[") {
+			section = line
+			continue
+		}
+		if section != "[package]" {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(k) == "name" {
+			return strings.Trim(strings.TrimSpace(v), `"'`), nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", fmt.Errorf("read %s: %w", manifest, err)
+	}
+	return "", fmt.Errorf("%s: no [package] name", manifest)
+}
+
 // ── Diff display ──────────────────────────────────────────────────────────────
 
 func diffLines(expected, actual string) {
@@ -153,7 +1532,12 @@ func diffLines(expected, actual string) {
 	if len(actLines) > n {
 		n = len(actLines)
 	}
+	colored := 0
 	for i := 0; i < n; i++ {
+		if maxDiffLines > 0 && i == maxDiffLines {
+			fmt.Printf("║ %-*s ║\n", 2*colW-1, fmt.Sprintf("... (output truncated, %d more lines)", n-i))
+			break
+		}
 		e, a := "", ""
 		if i < len(expLines) {
 			e = expLines[i]
@@ -161,9 +1545,18 @@ func diffLines(expected, actual string) {
 		if i < len(actLines) {
 			a = actLines[i]
 		}
-		if e != a {
-			fmt.Printf("║ \033[31m%-*s\033[0m ║ \033[32m%-*s\033[0m ║\n",
-				colW-2, truncate(e, colW-2), colW-2, truncate(a, colW-2))
+		differ := e != a
+		if showWhitespaceFlag {
+			if i < len(expLines) {
+				e = markWhitespace(e)
+			}
+			if i < len(actLines) {
+				a = markWhitespace(a)
+			}
+		}
+		if differ {
+			printColoredRow(rowTheme(colored), e, a, colW)
+			colored++
 		} else {
 			fmt.Printf("║ %-*s ║ %-*s ║\n",
 				colW-2, truncate(e, colW-2), colW-2, truncate(a, colW-2))
@@ -172,9 +1565,293 @@ func diffLines(expected, actual string) {
 	fmt.Printf("╚%s╩%s╝\n", sep, sep)
 }
 
+// sizeMismatchMin is the output size from which differing byte counts are
+// reported as a one-line summary instead of a diff table (see --force-diff).
+const sizeMismatchMin = 64 << 10
+
+// renderDiff prints the diff, through the pager with --pager (see
+// cf_pager.go).
+func renderDiff(expected, actual string) {
+	if pagerFlag && !jsonFlag && !diffJSONFlag {
+		if rows, ok := terminalHeight(); ok {
+			pageOutput(rows, func() { printDiff(expected, actual) })
+			return
+		}
+	}
+	printDiff(expected, actual)
+}
+
+// printDiff picks the diff renderer: JSON with --diff-json, a size summary for large outputs whose
+// byte counts differ (unless --force-diff), the aligned grid view when
+// --diff-grid is set and both sides are numeric grids, else the line table.
+func printDiff(expected, actual string) {
+	if diffJSONFlag {
+		data, _ := json.Marshal(diffJSON(expected, actual))
+		fmt.Println(string(data))
+		return
+	}
+	if !forceDiffFlag && len(expected) != len(actual) && max(len(expected), len(actual)) >= sizeMismatchMin {
+		sizeMismatch(expected, actual)
+		return
+	}
+	if diffGridFlag {
+		if exp, ok := parseGrid(expected); ok {
+			if act, ok := parseGrid(actual); ok {
+				diffGrid(exp, act)
+				return
+			}
+		}
+	}
+	if diffVerticalFlag {
+		diffStacked(expected, actual)
+		return
+	}
+	diffLines(expected, actual)
+}
+
+// diffStacked is the --diff-side vertical layout: each differing line is
+// shown in full, expected above actual, so nothing depends on the width of
+// the terminal.
+func diffStacked(expected, actual string) {
+	expLines := strings.Split(strings.TrimRight(expected, "\n"), "\n")
+	actLines := strings.Split(strings.TrimRight(actual, "\n"), "\n")
+	n := max(len(expLines), len(actLines))
+	shown, differing := 0, 0
+	for i := 0; i < n; i++ {
+		e, a := "", ""
+		if i < len(expLines) {
+			e = expLines[i]
+		}
+		if i < len(actLines) {
+			a = actLines[i]
+		}
+		if e == a {
+			continue
+		}
+		if differing++; maxDiffLines > 0 && shown == maxDiffLines {
+			continue
+		}
+		if showWhitespaceFlag {
+			e, a = markWhitespace(e), markWhitespace(a)
+		}
+		if i >= len(expLines) {
+			e = "(no line)"
+		}
+		if i >= len(actLines) {
+			a = "(no line)"
+		}
+		theme := rowTheme(shown)
+		fmt.Printf("  line %d\n", i+1)
+		fmt.Printf("    expected │ %s%s%s\n", theme.expected, e, theme.reset)
+		fmt.Printf("    actual   │ %s%s%s\n", theme.actual, a, theme.reset)
+		shown++
+	}
+	if differing > shown {
+		fmt.Printf("  ... (%d more differing lines)\n", differing-shown)
+	}
+}
+
+// diffLine is one line of the --diff-json view. Expected or Actual is nil
+// past the end of that side.
+type diffLine struct {
+	LineNo   int     `json:"lineNo"`
+	Expected *string `json:"expected"`
+	Actual   *string `json:"actual"`
+	Equal    bool    `json:"equal"`
+}
+
+// diffJSON pairs the lines of both outputs for --diff-json. Unlike the
+// tables it is never truncated; rendering is up to the consumer.
+func diffJSON(expected, actual string) []diffLine {
+	expLines := strings.Split(strings.TrimRight(expected, "\n"), "\n")
+	actLines := strings.Split(strings.TrimRight(actual, "\n"), "\n")
+	n := max(len(expLines), len(actLines))
+	lines := make([]diffLine, n)
+	for i := range lines {
+		lines[i].LineNo = i + 1
+		if i < len(expLines) {
+			lines[i].Expected = &expLines[i]
+		}
+		if i < len(actLines) {
+			lines[i].Actual = &actLines[i]
+		}
+		lines[i].Equal = lines[i].Expected != nil && lines[i].Actual != nil && *lines[i].Expected == *lines[i].Actual
+	}
+	return lines
+}
+
+// sizeMismatch summarises two outputs of different sizes without diffing them.
+func sizeMismatch(expected, actual string) {
+	fmt.Printf("  size mismatch: expected %d bytes (%d lines), actual %d bytes (%d lines)\n",
+		len(expected), strings.Count(expected, "\n"), len(actual), strings.Count(actual, "\n"))
+	fmt.Println("  (use --force-diff for the full table)")
+}
+
+// parseGrid splits s into rows of whitespace-separated numeric cells. ok is
+// false unless every token is a number and at least one row has two or more
+// cells, i.e. the output actually looks like a matrix.
+func parseGrid(s string) ([][]string, bool) {
+	var grid [][]string
+	wide := false
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		cells := strings.Fields(line)
+		for _, c := range cells {
+			if _, err := strconv.ParseFloat(c, 64); err != nil {
+				return nil, false
+			}
+		}
+		if len(cells) > 1 {
+			wide = true
+		}
+		grid = append(grid, cells)
+	}
+	return grid, wide
+}
+
+// diffGrid prints expected and actual grids side by side with every column
+// right-aligned to a common width; cells that differ are coloured.
+func diffGrid(exp, act [][]string) {
+	rows := len(exp)
+	if len(act) > rows {
+		rows = len(act)
+	}
+	var width []int
+	for _, g := range [][][]string{exp, act} {
+		for _, row := range g {
+			for j, c := range row {
+				if j == len(width) {
+					width = append(width, 0)
+				}
+				if len(c) > width[j] {
+					width[j] = len(c)
+				}
+			}
+		}
+	}
+	cellAt := func(g [][]string, i, j int) string {
+		if i < len(g) && j < len(g[i]) {
+			return g[i][j]
+		}
+		return ""
+	}
+	render := func(g, other [][]string, i int, color string) string {
+		cells := make([]string, len(width))
+		for j := range width {
+			c := fmt.Sprintf("%*s", width[j], cellAt(g, i, j))
+			if cellAt(g, i, j) != cellAt(other, i, j) {
+				c = color + c + colorTheme.reset
+			}
+			cells[j] = c
+		}
+		return strings.Join(cells, "  ")
+	}
+
+	colW := 0
+	for _, w := range width {
+		colW += w + 2
+	}
+	colW -= 2
+	if colW < len("Expected") {
+		colW = len("Expected")
+	}
+
+	diffCells := 0
+	for i := 0; i < rows; i++ {
+		for j := range width {
+			if cellAt(exp, i, j) != cellAt(act, i, j) {
+				diffCells++
+			}
+		}
+	}
+	fmt.Printf("┌─ Grid diff  (%d cell(s) differ)\n", diffCells)
+	fmt.Printf("│  %5s  %-*s │ %s\n", "row", colW, "Expected", "Actual")
+	for i := 0; i < rows; i++ {
+		if maxDiffLines > 0 && i == maxDiffLines {
+			fmt.Printf("│  ... (output truncated, %d more lines)\n", rows-i)
+			break
+		}
+		e := render(exp, act, i, colorTheme.expected)
+		a := render(act, exp, i, colorTheme.actual)
+		pad := colW - (len(width)*2 - 2)
+		for _, w := range width {
+			pad -= w
+		}
+		fmt.Printf("│  %5d  %s%s │ %s\n", i+1, e, strings.Repeat(" ", pad), a)
+	}
+	fmt.Println("└────────────────────────────────────────────────────────────────────────────")
+}
+
+// rowTheme is the theme for the differing row with index n (from 0):
+// colorTheme for the first --max-color-rows, plain after that, as escape
+// codes on thousands of rows slow some terminals down badly.
+func rowTheme(n int) diffTheme {
+	if maxColorRows > 0 && n >= maxColorRows {
+		return diffTheme{}
+	}
+	return colorTheme
+}
+
+// diffTheme holds the ANSI codes used for mismatching diff rows.
+type diffTheme struct {
+	expected string
+	actual   string
+	reset    string
+}
+
+var diffThemes = map[string]diffTheme{
+	"dark":  {expected: "\033[31m", actual: "\033[32m", reset: "\033[0m"},     // red / green
+	"light": {expected: "\033[1;31m", actual: "\033[1;34m", reset: "\033[0m"}, // bold red / bold blue
+	"none":  {},
+}
+
+// printColoredRow prints one mismatching diff row using the theme's colours.
+func printColoredRow(theme diffTheme, e, a string, colW int) {
+	fmt.Printf("║ %s%-*s%s ║ %s%-*s%s ║\n",
+		theme.expected, colW-2, truncate(e, colW-2), theme.reset,
+		theme.actual, colW-2, truncate(a, colW-2), theme.reset)
+}
+
+// printInput echoes the test input (at most showInputLines lines) so a
+// failing case can be debugged without opening the file. With --binary the
+// input is shown as a hex dump, 16 bytes per line.
+func printInput(inputFile string) {
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		fmt.Printf("  (cannot read input: %v)\n", err)
+		return
+	}
+	if binaryInputFlag {
+		fmt.Printf("── Input (%s, %d bytes) ──\n", inputFile, len(data))
+		if limit := showInputLines * 16; len(data) > limit {
+			fmt.Print(hex.Dump(data[:limit]))
+			fmt.Printf("... (%d more bytes)\n", len(data)-limit)
+		} else {
+			fmt.Print(hex.Dump(data))
+		}
+		return
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	fmt.Printf("── Input (%s) ──\n", inputFile)
+	for i, l := range lines {
+		if i == showInputLines {
+			fmt.Printf("... (%d more lines)\n", len(lines)-i)
+			break
+		}
+		fmt.Println(l)
+	}
+}
+
+// markWhitespace makes trailing whitespace visible: spaces become '·',
+// tabs '→', a stray '\r' '␍', and the line end is marked with '⏎'.
+func markWhitespace(line string) string {
+	body := strings.TrimRight(line, " \t\r")
+	tail := strings.NewReplacer(" ", "·", "\t", "→", "\r", "␍").Replace(line[len(body):])
+	return body + tail + "⏎"
+}
+
 func truncate(s string, max int) string {
-	if len(s) > max {
-		return s[:max-3] + "..."
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-3]) + "..."
 	}
 	return s
 }
@@ -222,8 +1899,298 @@ func parseCLI(argv []string) (cliInvocation, error) {
 		switch arg {
 		case "-v", "--verbose":
 			verboseFlag = true
+		case "--show-command-duration":
+			showDurationsFlag = true
 		case "--cleanup":
 			cleanupFlag = true
+		case "--no-build-dir":
+			noBuildDirFlag = true
+		case "--cache-dir":
+			v, err := next(arg); if err != nil { return inv, err }
+			cacheDir = v
+		case "--dump-commands":
+			dumpCommandsFlag = true
+		case "--explain":
+			explainFlag = true
+		case "--subtract-jvm-warmup":
+			subtractJVMWarmupFlag = true
+		case "--bench":
+			v, err := next(arg); if err != nil { return inv, err }
+			benchOther = v
+		case "--cpu":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--cpu: %w", err) }
+			if n < 0 { return inv, fmt.Errorf("--cpu: must be >= 0, got %d", n) }
+			cpuCore = n
+		case "--bench-runs":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--bench-runs: %w", err) }
+			if n < 1 { return inv, fmt.Errorf("--bench-runs: must be >= 1, got %d", n) }
+			benchRuns = n
+		case "--verify-tests":
+			v, err := next(arg); if err != nil { return inv, err }
+			verifyTestsDir = v
+		case "--tests":
+			v, err := next(arg); if err != nil { return inv, err }
+			testsDir = v
+		case "--solutions-dir":
+			v, err := next(arg); if err != nil { return inv, err }
+			solutionsDir = v
+		case "--golden-dir":
+			v, err := next(arg); if err != nil { return inv, err }
+			goldenDir = v
+		case "--repl":
+			replFlag = true
+		case "--embedded":
+			embeddedFlag = true
+		case "--minimize":
+			minimizeFlag = true
+		case "--split-on":
+			v, err := next(arg); if err != nil { return inv, err }
+			splitOn = v
+		case "--runs":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--runs: %w", err) }
+			if n < 1 { return inv, fmt.Errorf("--runs: must be >= 1, got %d", n) }
+			runsCount = n
+		case "--max-tests":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--max-tests: %w", err) }
+			if n < 0 { return inv, fmt.Errorf("--max-tests: must be >= 0, got %d", n) }
+			maxTests = n
+		case "--subtasks":
+			v, err := next(arg); if err != nil { return inv, err }
+			subtasksFile = v
+		case "--from-zip":
+			v, err := next(arg); if err != nil { return inv, err }
+			fromZip = v
+		case "--lang":
+			v, err := next(arg); if err != nil { return inv, err }
+			langOverride = strings.ToLower(v)
+		case "--shell-bin":
+			v, err := next(arg); if err != nil { return inv, err }
+			shellBin = v
+		case "--time-limit":
+			v, err := next(arg); if err != nil { return inv, err }
+			d, err := parseDurationFlag(v); if err != nil { return inv, fmt.Errorf("--time-limit: %w", err) }
+			timeLimit = d
+		case "--time-format":
+			v, err := next(arg); if err != nil { return inv, err }
+			if v != "ms" && v != "s" && v != "auto" { return inv, fmt.Errorf("--time-format: want ms, s or auto, got %q", v) }
+			timeFormat = v
+		case "--cpu-timeout":
+			v, err := next(arg); if err != nil { return inv, err }
+			d, err := parseDurationFlag(v); if err != nil { return inv, fmt.Errorf("--cpu-timeout: %w", err) }
+			cpuTimeout = d
+		case "--memory-limit":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := parseMemoryFlag(v); if err != nil { return inv, fmt.Errorf("--memory-limit: %w", err) }
+			memoryLimit = n
+		case "--limits-from-header":
+			limitsFromHeaderFlag = true
+		case "--sanitize":
+			v, err := next(arg); if err != nil { return inv, err }
+			sanitizers = append(sanitizers, strings.Split(v, ",")...)
+		case "--include":
+			v, err := next(arg); if err != nil { return inv, err }
+			cppIncludes = append(cppIncludes, v)
+		case "--python-bins":
+			v, err := next(arg); if err != nil { return inv, err }
+			pythonBins = strings.Split(v, ",")
+		case "--go-tags":
+			v, err := next(arg); if err != nil { return inv, err }
+			goTags = v
+		case "--go-ldflags":
+			v, err := next(arg); if err != nil { return inv, err }
+			goLDFlags = v
+		case "--libs":
+			v, err := next(arg); if err != nil { return inv, err }
+			cppLibs = append(cppLibs, strings.Fields(v)...)
+		case "--compile-memory-limit":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := parseMemoryFlag(v); if err != nil { return inv, fmt.Errorf("--compile-memory-limit: %w", err) }
+			compileMemoryLimit = n
+		case "--jobs":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--jobs: %w", err) }
+			if n < 1 { return inv, fmt.Errorf("--jobs: must be >= 1, got %d", n) }
+			jobs = n
+		case "--max-parallel-memory":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := parseMemoryFlag(v); if err != nil { return inv, fmt.Errorf("--max-parallel-memory: %w", err) }
+			maxParallelMemory = n
+		case "--compile-jobs":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--compile-jobs: %w", err) }
+			if n < 0 { return inv, fmt.Errorf("--compile-jobs: must be >= 0, got %d", n) }
+			compileJobs = n
+		case "--toolchain":
+			v, err := next(arg); if err != nil { return inv, err }
+			for _, kv := range strings.Split(v, ",") {
+				key, path, ok := strings.Cut(kv, "=")
+				if _, known := toolchainKeys[key]; !ok || !known || path == "" {
+					return inv, fmt.Errorf("--toolchain: want lang=path with lang one of cpp, go, rust, cargo, fortran, dlang, zig, java, javac, python; got %q", kv)
+				}
+				toolchain[key] = path
+			}
+		case "--d-compiler":
+			v, err := next(arg); if err != nil { return inv, err }
+			if v != "dmd" && v != "ldc2" { return inv, fmt.Errorf("--d-compiler: want dmd or ldc2, got %q", v) }
+			dCompiler = v
+		case "--no-compile-output":
+			noCompileOutputFlag = true
+		case "--workdir":
+			v, err := next(arg); if err != nil { return inv, err }
+			workDir = v
+		case "--locale":
+			v, err := next(arg); if err != nil { return inv, err }
+			runLocale = v
+		case "--compile-timeout":
+			v, err := next(arg); if err != nil { return inv, err }
+			d, err := parseDurationFlag(v); if err != nil { return inv, fmt.Errorf("--compile-timeout: %w", err) }
+			compileTimeout = d
+		case "--json":
+			jsonFlag = true
+		case "--badge":
+			badgeFormat = "token"
+		case "--badge-url":
+			badgeFormat = "shields"
+		case "--junit":
+			v, err := next(arg); if err != nil { return inv, err }
+			junitPath = v
+		case "--progress-json":
+			progressJSONFlag = true
+		case "--docker":
+			v, err := next(arg); if err != nil { return inv, err }
+			dockerImage = v
+		case "--pch":
+			pchFlag = true
+		case "--ignore-trailing-newline":
+			ignoreTrailingNewlineFlag = true
+		case "--allow-extra-trailing-blanks":
+			allowTrailingBlanksFlag = true
+		case "--strip-one-newline":
+			stripOneNewlineFlag = true
+		case "--max-line-length":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--max-line-length: %w", err) }
+			if n < 0 { return inv, fmt.Errorf("--max-line-length: must be >= 0, got %d", n) }
+			maxLineLength = n
+		case "--keep-outputs":
+			keepOutputsFlag = true
+		case "--tee":
+			teeFlag = true
+		case "--binary":
+			binaryInputFlag = true
+		case "--color-theme":
+			v, err := next(arg); if err != nil { return inv, err }
+			t, ok := diffThemes[v]; if !ok { return inv, fmt.Errorf("--color-theme: unknown theme %q (dark, light, none)", v) }
+			colorTheme = t
+		case "--max-color-rows":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--max-color-rows: %w", err) }
+			if n < 0 { return inv, fmt.Errorf("--max-color-rows: must be >= 0, got %d", n) }
+			maxColorRows = n
+		case "--diff-side":
+			v, err := next(arg); if err != nil { return inv, err }
+			switch v {
+			case "side": diffVerticalFlag = false
+			case "vertical", "stacked": diffVerticalFlag = true
+			default: return inv, fmt.Errorf("--diff-side: want side or vertical, got %q", v)
+			}
+		case "--max-lines":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--max-lines: %w", err) }
+			if n < 0 { return inv, fmt.Errorf("--max-lines: must be >= 0, got %d", n) }
+			maxDiffLines = n
+		case "--warmup":
+			warmupFlag = true
+		case "--require-exit-zero":
+			requireExitZeroFlag = true
+		case "--expected-exit-code":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--expected-exit-code: %w", err) }
+			if n < 0 || n > 255 { return inv, fmt.Errorf("--expected-exit-code: must be 0-255, got %d", n) }
+			expectedExitCode = n
+		case "--diff-grid":
+			diffGridFlag = true
+		case "--compare":
+			v, err := next(arg); if err != nil { return inv, err }
+			modes := strings.Split(v, ",")
+			for _, m := range modes {
+				if _, ok := comparators[m]; !ok { return inv, fmt.Errorf("--compare: unknown comparator %q (%s)", m, strings.Join(comparatorNames(), ", ")) }
+			}
+			compareModes = modes
+		case "--expected-regex":
+			compareModes = []string{"regex"}
+		case "--unordered-tokens":
+			compareModes = []string{"unordered-tokens"}
+		case "--diff-bytes":
+			compareModes = []string{"bytes"}
+		case "--eps":
+			v, err := next(arg); if err != nil { return inv, err }
+			f, err := strconv.ParseFloat(v, 64); if err != nil { return inv, fmt.Errorf("--eps: %w", err) }
+			floatEps = f
+		case "--ignore-lines":
+			v, err := next(arg); if err != nil { return inv, err }
+			re, err := regexp.Compile(v); if err != nil { return inv, fmt.Errorf("--ignore-lines: %w", err) }
+			ignoreLines = re
+		case "--compare-stream":
+			v, err := next(arg); if err != nil { return inv, err }
+			re, err := regexp.Compile(v); if err != nil { return inv, fmt.Errorf("--compare-stream: %w", err) }
+			compareStream = re
+		case "--compare-tail":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--compare-tail: %w", err) }
+			if n < 0 { return inv, fmt.Errorf("--compare-tail: must be >= 0, got %d", n) }
+			compareTail = n
+		case "--strip-trailing-spaces":
+			stripTrailingSpacesFlag = true
+		case "--show-whitespace":
+			showWhitespaceFlag = true
+		case "--capture-core":
+			captureCoreFlag = true
+		case "--diff-json":
+			diffJSONFlag = true
+		case "--pager":
+			pagerFlag = true
+		case "--force-diff":
+			forceDiffFlag = true
+		case "--expected-cmd":
+			v, err := next(arg); if err != nil { return inv, err }
+			expectedCmd = v
+		case "--name":
+			v, err := next(arg); if err != nil { return inv, err }
+			runName = v
+		case "--save-expected":
+			v, err := next(arg); if err != nil { return inv, err }
+			saveExpected = v
+		case "--output-filter":
+			v, err := next(arg); if err != nil { return inv, err }
+			outputFilter = v
+		case "--input-filter":
+			v, err := next(arg); if err != nil { return inv, err }
+			inputFilter = v
+		case "--gen-cmd":
+			v, err := next(arg); if err != nil { return inv, err }
+			genCmd = v
+		case "--stdin":
+			v, err := next(arg); if err != nil { return inv, err }
+			inlineStdin = v
+		case "--fail-on-stderr":
+			failOnStderrFlag = true
+		case "--detect-pe":
+			detectPEFlag = true
+		case "--show-input":
+			showInputFlag = true
+		case "--show-input-lines":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--show-input-lines: %w", err) }
+			if n < 1 { return inv, fmt.Errorf("--show-input-lines: must be >= 1, got %d", n) }
+			showInputLines = n
+		case "--fail-message":
+			v, err := next(arg); if err != nil { return inv, err }
+			failMessage = v
 
 		case "--cf-user":
 			v, err := next(arg); if err != nil { return inv, err }
@@ -245,6 +2212,11 @@ func parseCLI(argv []string) (cliInvocation, error) {
 		case "--cf-key":
 			v, err := next(arg); if err != nil { return inv, err }
 			inv.cfKey = v
+		case "--cf-max-attempts":
+			v, err := next(arg); if err != nil { return inv, err }
+			n, err := strconv.Atoi(v); if err != nil { return inv, fmt.Errorf("--cf-max-attempts: %w", err) }
+			if n < 1 { return inv, fmt.Errorf("--cf-max-attempts: must be >= 1, got %d", n) }
+			cfMaxAttempts = n
 		case "--cf-secret":
 			v, err := next(arg); if err != nil { return inv, err }
 			inv.cfSecret = v
@@ -260,9 +2232,21 @@ func parseCLI(argv []string) (cliInvocation, error) {
 			}
 		}
 	}
+	if binaryInputFlag && inputFilter != "" {
+		return inv, fmt.Errorf("--binary takes no --input-filter, which rewrites the input")
+	}
 	return inv, nil
 }
 
+// parseDurationFlag accepts a Go duration ("30s", "1m30s", "500ms") or a
+// bare number of seconds ("10", "2.5").
+func parseDurationFlag(v string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return time.ParseDuration(v)
+}
+
 // ── Usage ─────────────────────────────────────────────────────────────────────
 
 func printUsage() {
@@ -281,8 +2265,98 @@ func printUsage() {
 	fmt.Println("    make test    PROB=A    (run + diff out.txt vs exp.txt)")
 	fmt.Println()
 	fmt.Println("Standalone local runner (no contest context needed):")
-	fmt.Println("  cfr <source> <in> <out> <exp>   compile, run, diff")
+	fmt.Println("  cfr <source> <in> <out> <exp>   compile, run, diff  (source - = stdin, needs --lang)")
 	fmt.Println("    flags: --cleanup  --verbose")
+	fmt.Println("           --no-build-dir              build in a temp dir instead of ./build, always removed")
+	fmt.Println("           --show-command-duration     log how long each compile/run/helper command took")
+	fmt.Println("           --time-format <auto|ms|s>   unit of printed times (default auto: ms below 1s, else s)")
+	fmt.Println("           --dump-commands             print the compile/run commands and exit")
+	fmt.Println("           --explain                   describe in words what the run would do and exit")
+	fmt.Println("           --ignore-trailing-newline   only ignore newlines at EOF (default: trim all whitespace)")
+	fmt.Println("           --allow-extra-trailing-blanks   only ignore blank lines at EOF, any number on either side")
+	fmt.Println("           --strip-one-newline         only ignore a single \\n at EOF, nothing else")
+	fmt.Println("           --warmup                    untimed run first, then the measured run")
+	fmt.Println("           --cpu N                     Linux: pin the solution to CPU N for steadier timings")
+	fmt.Println("           --runs N                    run N times, flag outputs that change between runs")
+	fmt.Println("           --split-on <delim>          cut <in> and <exp> into cases at lines equal to delim")
+	fmt.Println("           --minimize                  with --expected-cmd: shrink a failing input to a small reproducer")
+	fmt.Println("           --require-exit-zero         nonzero exit code is RE even if output matches")
+	fmt.Println("           --expected-exit-code N      exit code other than N is RE")
+	fmt.Println("           --fail-on-stderr            any stderr output is RE even if stdout matches (shown below)")
+	fmt.Println("           --strip-trailing-spaces     ignore trailing spaces/tabs on each line")
+	fmt.Println("           --show-whitespace           mark trailing whitespace (·, →) and line ends (⏎) in the diff")
+	fmt.Println("           --force-diff                diff large outputs even when their sizes differ")
+	fmt.Println("           --diff-json                 print the diff as JSON lines {lineNo, expected, actual, equal}")
+	fmt.Println("           --pager                     on a terminal, show a diff taller than the screen through $PAGER (less -R)")
+	fmt.Println("           --compare <bytes|exact|float|mixed|numeric|regex|sorted|tokens|unordered-tokens>[,…]   comparator, or a chain tried in order (default exact)")
+	fmt.Println("           --expected-regex            expected lines are regexps matched against the actual lines (= --compare regex)")
+	fmt.Println("           --unordered-tokens          compare the multisets of tokens, lines and order ignored (= --compare unordered-tokens)")
+	fmt.Println("           --diff-bytes                compare the outputs with all whitespace removed (= --compare bytes)")
+	fmt.Println("           --eps <1e-6>                tolerance for --compare float")
+	fmt.Println("           --ignore-lines <regex>      drop matching lines from both outputs before comparing")
+	fmt.Println("           --compare-stream <regex>    compare only the lines matching it, e.g. the solution's side of a log")
+	fmt.Println("           --compare-tail N            only compare the last N lines of both outputs")
+	fmt.Println("           --detect-pe                 Presentation Error when only whitespace differs")
+	fmt.Println("           --show-input [--show-input-lines N]   print the input on WA/RE (default 20 lines)")
+	fmt.Println("           --fail-message <template>   print this line on every non-AC verdict: {verdict} {test} {time} {exit}")
+	fmt.Println("           --capture-core              Linux: allow core dumps and report where a crash's core went")
+	fmt.Println("           --lang <cpp|go|rust|java|python|bash|fortran|dlang|zig>   force language, skip extension detection")
+	fmt.Println("           --shell-bin <sh|zsh|…>      interpreter for .sh solutions (default bash)")
+	fmt.Println("           --compile-timeout <30s>     kill the compiler if it runs longer")
+	fmt.Println("           --subtract-jvm-warmup       Java: report run time minus a bare JVM startup")
+	fmt.Println("           --time-limit <2s>           kill the solution after this long (TLE)")
+	fmt.Println("           --cpu-timeout <2s>          Linux: TLE once the solution used this much CPU time (RLIMIT_CPU)")
+	fmt.Println("           --memory-limit <256m>       peak memory above this is MLE (Linux)")
+	fmt.Println("           --limits-from-header        read `time: 2s mem: 256m` from the source's top comment")
+	fmt.Println("           --docker <image>            compile and run inside a container")
+	fmt.Println("           --workdir <dir>             directory the solution runs in (default: the source's)")
+	fmt.Println("           --locale <C>                LC_ALL/LANG of the solution (default C), inherit = keep yours")
+	fmt.Println("           --cache-dir <dir>           reuse compiled binaries across runs and projects (e.g. ~/.cache/cfr)")
+	fmt.Println("           --sanitize <address|undefined>[,…]   C++: build with sanitizers, report below the verdict")
+	fmt.Println("           --include <dir>             C++: add -I<dir> for shared headers (repeatable)")
+	fmt.Println("           --libs \"-lm -lpthread\"      C++: link flags placed after the source file")
+	fmt.Println("           --go-tags <a,b>             Go: go build -tags")
+	fmt.Println("           --go-ldflags \"-s -w\"        Go: go build -ldflags")
+	fmt.Println("           --compile-memory-limit <2g> cap the compiler's address space (Linux)")
+	fmt.Println("           --compile-jobs N            parallel build jobs for cargo / make (cargo -j, make -j)")
+	fmt.Println("           --d-compiler <dmd|ldc2>     compiler for .d solutions (default dmd)")
+	fmt.Println("           --toolchain lang=path[,…]   pin the compiler/interpreter per language, e.g. go=/usr/lib/go-1.22/bin/go")
+	fmt.Println("           --python-bins a,b           run a Python source under each interpreter, compare verdicts/times")
+	fmt.Println("           --no-compile-output         show compiler output only when the compile fails")
+	fmt.Println("           --pch                       C++: precompile <bits/stdc++.h> into build/pch")
+	fmt.Println("           --progress-json             stream NDJSON test events to stderr")
+	fmt.Println("           --binary                    byte-exact stdin, no input normalisation")
+	fmt.Println("           --input-filter \"cmd\"        pipe the input through cmd before the solution")
+	fmt.Println("           --output-filter \"cmd\"       pipe the actual output through cmd before comparing")
+	fmt.Println("           --save-expected <path>      run without comparing and save the normalised output as <path>")
+	fmt.Println("           --name <label>              header before the run, also in --json / --junit reports")
+	fmt.Println("           --stdin \"5\\n1 2 3\"          inline input instead of an <input> file (\\n, \\t escapes)")
+	fmt.Println("           --gen-cmd \"cmd\"             stream cmd's stdout into the solution instead of an <input> file")
+	fmt.Println("           --expected-cmd \"cmd\"        expected output = stdout of cmd on the input (<expected> optional)")
+	fmt.Println("           --tee                       also print the solution's stdout live")
+	fmt.Println("           --max-line-length N         warn about output lines longer than N chars")
+	fmt.Println("           --color-theme <dark|light|none>   diff colours (default dark)")
+	fmt.Println("           --diff-side <side|vertical>   vertical: expected above actual, for narrow terminals")
+	fmt.Println("           --diff-grid                 align numeric grids, highlight differing cells")
+	fmt.Println("           --max-lines N               cap diff table rows (default 100, 0 = no cap)")
+	fmt.Println("           --max-color-rows N          colour only the first N differing rows, the rest plain")
+	fmt.Println()
+	fmt.Println("  cfr --from-zip <archive.zip>    unpack source + tests, run them all, clean up")
+	fmt.Println("  cfr <source> <in> --bench <other> [--bench-runs 5]   time two solutions on the same input")
+	fmt.Println("  cfr --verify-tests <dir>        check test pairs: missing/empty/stray files, runs nothing")
+	fmt.Println("  cfr <source> --tests <dir>      run every <name>.in/.out pair under dir")
+	fmt.Println("    flags: --subtasks <spec.json>  score all-or-nothing test groups (also with --from-zip)")
+	fmt.Println("           --max-tests N           run only the first N tests (natural order)")
+	fmt.Println("           --keep-outputs          keep each test's output as build/<test>.actual")
+	fmt.Println("           --json                  print one JSON report (tests + summary) instead of text")
+	fmt.Println("           --junit <path>          also write a JUnit XML report for CI")
+	fmt.Println("           --badge | --badge-url   end with a one-line summary (AC|124ms|cpp) or shields.io URL")
+	fmt.Println("  cfr <source> --embedded        run the INPUT … OUTPUT … examples in the source's comments")
+	fmt.Println("  cfr <source> --repl            run the solution with your terminal as the (interactive) judge")
+	fmt.Println("  cfr --solutions-dir <dir> <in> <exp> | --tests <dir>   run every source in dir, one verdict row each")
+	fmt.Println("  cfr --golden-dir <dir>                                 run each problem subdir's solution on its own tests")
+	fmt.Println("    flags: --jobs N                compile up to N solutions at once (default: CPU count)")
+	fmt.Println("           --max-parallel-memory <4g>   fewer jobs so N × --compile-memory-limit stays within this")
 	fmt.Println()
 	fmt.Println("CF API queries:")
 	fmt.Println("  cfr --cf-user <handle>")
@@ -291,6 +2365,7 @@ func printUsage() {
 	fmt.Println("  cfr --cf-tags <dp,greedy>      search by tags")
 	fmt.Println("  cfr --cf-verdict <contestId>")
 	fmt.Println("  cfr --cf-key <k> --cf-secret <s>  (or CF_API_KEY / CF_API_SECRET env)")
+	fmt.Println("    flags: --cf-max-attempts N     retries on 429/5xx/call limit with backoff (default 4)")
 }
 
 // ── Entry point ───────────────────────────────────────────────────────────────
@@ -301,6 +2376,7 @@ func main() {
 		fmt.Fprintln(os.Stderr, "error:", err)
 		os.Exit(1)
 	}
+	handleInterrupts()
 
 	switch inv.command {
 	case "help":
@@ -364,31 +2440,216 @@ func main() {
 		return
 	}
 
+	if saveExpected != "" && (verifyTestsDir != "" || fromZip != "" || solutionsDir != "" || goldenDir != "" || testsDir != "" || embeddedFlag) {
+		fatalf("--save-expected works on a single run: <source> <input> <output>")
+	}
+	if noBuildDirFlag && (verifyTestsDir != "" || fromZip != "" || solutionsDir != "" || goldenDir != "" || testsDir != "" || embeddedFlag || replFlag || benchOther != "") {
+		fatalf("--no-build-dir works on a single run: <source> <input> <output> <expected>")
+	}
+	if verifyTestsDir != "" {
+		if err := VerifyTests(verifyTestsDir); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if fromZip != "" {
+		if err := RunZip(fromZip); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+
+	// Regression mode: cfr --golden-dir <dir>
+	if goldenDir != "" {
+		if len(inv.args) != 0 || testsDir != "" || solutionsDir != "" {
+			fatalf("--golden-dir takes no other source or tests: each subdirectory brings its own")
+		}
+		if err := RunGolden(goldenDir); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+
+	// Batch mode: cfr --solutions-dir <dir> <in> <exp> | --tests <dir>
+	if solutionsDir != "" {
+		var tests []testCase
+		switch {
+		case testsDir != "" && len(inv.args) == 0:
+			if tests, err = discoverTests(testsDir); err != nil {
+				fatalf("%v", err)
+			}
+			if len(tests) == 0 {
+				fatalf("%s: no tests found", testsDir)
+			}
+			if err := applyLimitsManifest(testsDir, tests); err != nil {
+				fatalf("%v", err)
+			}
+		case testsDir == "" && len(inv.args) == 2:
+			tests = []testCase{{Name: filepath.Base(inv.args[0]), Input: inv.args[0], Expected: inv.args[1]}}
+		default:
+			fatalf("--solutions-dir expects <input> <expected> or --tests <dir>")
+		}
+		if err := RunSolutions(solutionsDir, tests); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+
 	// Standalone local runner: cfr <source> <in> <out> <exp>
 	if len(inv.args) == 0 {
 		printUsage()
 		os.Exit(1)
 	}
+	if benchOther != "" {
+		if len(inv.args) != 2 && len(inv.args) != 4 {
+			fatalf("--bench expects <source> <input>")
+		}
+		if err := RunBench(inv.args[0], benchOther, inv.args[1]); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if jsonFlag && testsDir == "" {
+		fatalf("--json reports multi-test runs, use it with --tests or --from-zip")
+	}
+	if replFlag {
+		if len(inv.args) != 1 {
+			fatalf("--repl expects exactly one <source>")
+		}
+		if err := RunREPL(inv.args[0]); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if embeddedFlag {
+		if len(inv.args) != 1 {
+			fatalf("--embedded expects exactly one <source>")
+		}
+		if err := RunEmbedded(inv.args[0]); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if testsDir != "" {
+		if len(inv.args) != 1 {
+			fatalf("--tests expects exactly one <source>")
+		}
+		if err := RunDir(inv.args[0], testsDir); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if genCmd != "" {
+		switch {
+		case inlineStdin != "":
+			fatalf("--gen-cmd and --stdin both replace the input")
+		case expectedCmd != "" || minimizeFlag || runsCount > 1 || splitOn != "":
+			fatalf("--gen-cmd streams the input without storing it; use an <expected> file, and no --minimize, --runs or --split-on")
+		case len(inv.args) > 0:
+			inv.args = append([]string{inv.args[0], ""}, inv.args[1:]...) // input comes from --gen-cmd
+		}
+	}
+	if inlineStdin != "" && len(inv.args) > 0 {
+		inv.args = append([]string{inv.args[0], ""}, inv.args[1:]...) // input comes from --stdin
+	}
+	if saveExpected != "" {
+		switch {
+		case expectedCmd != "" || minimizeFlag || splitOn != "":
+			fatalf("--save-expected writes the expected file; it takes no --expected-cmd, --minimize or --split-on")
+		case len(inv.args) != 3:
+			fatalf("--save-expected expects <source> <input> <output>")
+		}
+		inv.args = append(inv.args, "") // written after the run, see saveGolden
+	}
+	if expectedCmd != "" && len(inv.args) == 3 {
+		inv.args = append(inv.args, "") // expected output comes from the command
+	}
 	if len(inv.args) != 4 {
 		fatalf("runner expects <source> <input> <output> <expected>")
 	}
 
+	buildDir := "build"
+	if noBuildDirFlag {
+		if minimizeFlag || splitOn != "" || len(pythonBins) > 0 {
+			fatalf("--no-build-dir takes no --minimize, --split-on or --python-bins, which keep their files in build/")
+		}
+		if buildDir, err = os.MkdirTemp("", "cfr-build-"); err != nil {
+			fatalf("--no-build-dir: %v", err)
+		}
+		tempBuildDir = buildDir
+		removeOnInterrupt(buildDir)
+		defer os.RemoveAll(buildDir)
+	}
+
 	src, in, out, exp := inv.args[0], inv.args[1], inv.args[2], inv.args[3]
-	lang, err := detectLang(src)
+	if src == "-" {
+		if src, err = sourceFromStdin(buildDir); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	if inlineStdin != "" {
+		if in, err = writeInlineStdin(buildDir); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	lang, err := resolveLang(src)
 	if err != nil {
 		fatalf("%v", err)
 	}
+	if dumpCommandsFlag {
+		if err := dumpCommands(lang, src, in, out); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if explainFlag {
+		if err := explainRun(lang, src, in, out, exp); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
 	for _, f := range []string{src, in, exp} {
+		if f == exp && (expectedCmd != "" || saveExpected != "") || f == in && genCmd != "" {
+			continue
+		}
 		if _, err := os.Stat(f); os.IsNotExist(err) {
 			fatalf("file not found: %s", f)
 		}
 	}
-	if err := compileAndRun(lang, src, in, out, exp); err != nil {
+	if minimizeFlag && expectedCmd == "" {
+		fatalf("--minimize needs --expected-cmd to judge the shrunk inputs")
+	}
+	if len(pythonBins) > 0 {
+		switch {
+		case lang != "python":
+			fatalf("--python-bins runs Python sources, %s is %s", src, lang)
+		case runsCount > 1 || minimizeFlag || splitOn != "" || saveExpected != "":
+			fatalf("--python-bins takes no --runs, --minimize, --split-on or --save-expected")
+		}
+		if err := RunPythonBins(src, in, out, exp); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if splitOn != "" {
+		if err := RunSplit(src, in, exp, splitOn); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if err := compileAndRun(lang, src, in, out, exp, buildDir); err != nil {
 		fatalf("%v", err)
 	}
 }
 
+// tempBuildDir is the --no-build-dir directory. fatalf removes it, as
+// os.Exit skips main's deferred removal.
+var tempBuildDir string
+
 func fatalf(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	if tempBuildDir != "" {
+		os.RemoveAll(tempBuildDir)
+	}
 	os.Exit(1)
 }
\ No newline at end of file
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestBinaryInputUnchanged(t *testing.T) {
+	if _, err := exec.LookPath(shellBin); err != nil {
+		t.Skipf("%s not found", shellBin)
+	}
+	defer func(v bool) { binaryInputFlag = v }(binaryInputFlag)
+	binaryInputFlag = true
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "cat.sh")
+	if err := os.WriteFile(src, []byte("cat\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	input := []byte("1 2\r\n\x00\x00x\r\n\x00")
+	in := filepath.Join(dir, "in.bin")
+	if err := os.WriteFile(in, input, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sol, err := buildSolution("bash", src, filepath.Join(dir, "build"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.txt")
+	if _, err := sol.runTest(1, testCase{Name: "in.bin", Input: in, Expected: in}, out); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, input) {
+		t.Errorf("solution read %q, want %q", got, input)
+	}
+}
+
+func TestBinaryInlineStdin(t *testing.T) {
+	defer func(v bool, s string) { binaryInputFlag, inlineStdin = v, s }(binaryInputFlag, inlineStdin)
+	binaryInputFlag, inlineStdin = true, `1\n2`+"\r"
+
+	path, err := writeInlineStdin(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != inlineStdin {
+		t.Errorf("stdin.txt = %q, want %q", got, inlineStdin)
+	}
+}
+
+func TestBinaryRejectsInputFilter(t *testing.T) {
+	defer func(v bool, s string) { binaryInputFlag, inputFilter = v, s }(binaryInputFlag, inputFilter)
+	if _, err := parseCLI([]string{"--binary", "--input-filter", "tr -d '\\r'", "a.cpp"}); err == nil {
+		t.Error("parseCLI accepted --binary with --input-filter")
+	}
+}